@@ -0,0 +1,40 @@
+package goredis
+
+// Redactor returns the arguments of cmd to hand an audit hook, e.g. with
+// sensitive values masked. It must not modify args.
+type Redactor func(cmd string, args []interface{}) []interface{}
+
+// RedactValues keeps the first argument of every command, usually its key, and
+// masks the other ones, which carry the values written
+func RedactValues(cmd string, args []interface{}) []interface{} {
+	redacted := make([]interface{}, len(args))
+	for i, arg := range args {
+		if i == 0 {
+			redacted[i] = arg
+		} else {
+			redacted[i] = "[redacted]"
+		}
+	}
+	return redacted
+}
+
+// WithCommandAudit calls audit with every Redis command the Service sends,
+// before it is sent, e.g. to keep an audit trail or log commands in tests. The
+// arguments go through redact first unless it is nil. A nil audit installs
+// nothing, leaving commands unwrapped. Commands sent while dialing, such as AUTH,
+// are not seen.
+func WithCommandAudit(audit func(cmd string, args []interface{}), redact Redactor) Option {
+	return func(s *Service) {
+		if audit == nil {
+			return
+		}
+		s.setStore(s.store.WithInterceptor(func(cmd string, args []interface{}, do func() (interface{}, error)) (interface{}, error) {
+			if redact != nil {
+				audit(cmd, redact(cmd, args))
+			} else {
+				audit(cmd, args)
+			}
+			return do()
+		}))
+	}
+}
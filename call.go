@@ -0,0 +1,213 @@
+package goredis
+
+import (
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+	"github.com/owngoals/go-redis/serializer"
+)
+
+// CallOption overrides the Service configuration for a single operation
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	serializer    serializer.Serializer
+	createMissing bool
+	retry         RetryPolicy
+	primary       bool
+	onReconnect   func(err error)
+	onDecodeError func(channel string, err error)
+	misses        bool
+	expiration    ExpirationPolicy
+}
+
+// WithInlineSerializer encodes and decodes the value of this call with s
+func WithInlineSerializer(s serializer.Serializer) CallOption {
+	return func(o *callOptions) {
+		o.serializer = s
+	}
+}
+
+// WithCreateIfMissing makes CompareAndSwap succeed and create the key when it does
+// not exist, instead of failing
+func WithCreateIfMissing() CallOption {
+	return func(o *callOptions) {
+		o.createMissing = true
+	}
+}
+
+// WithRetryPolicy overrides the Service retry policy for this call
+func WithRetryPolicy(p RetryPolicy) CallOption {
+	return func(o *callOptions) {
+		o.retry = p
+	}
+}
+
+// WithExpirationPolicy overrides the Service expiration policy for this read
+func WithExpirationPolicy(p ExpirationPolicy) CallOption {
+	return func(o *callOptions) {
+		o.expiration = p
+	}
+}
+
+// WithPrimaryRead reads from the primary even when WithReplicas is set
+func WithPrimaryRead() CallOption {
+	return func(o *callOptions) {
+		o.primary = true
+	}
+}
+
+// WithReconnectHandler makes Subscribe call fn with the error that dropped the
+// subscription before each reconnection attempt
+func WithReconnectHandler(fn func(err error)) CallOption {
+	return func(o *callOptions) {
+		o.onReconnect = fn
+	}
+}
+
+// WithDecodeErrorHandler makes SubscribeTyped call fn with the error of every
+// message it skips because its payload does not decode
+func WithDecodeErrorHandler(fn func(channel string, err error)) CallOption {
+	return func(o *callOptions) {
+		o.onDecodeError = fn
+	}
+}
+
+// WithMisses makes GetMulti map missing keys to nil instead of leaving them out
+func WithMisses() CallOption {
+	return func(o *callOptions) {
+		o.misses = true
+	}
+}
+
+// callOptions applies opts over the Service defaults
+func (s *Service) callOptions(opts []CallOption) *callOptions {
+	o := &callOptions{serializer: s.codec(), retry: s.retry, expiration: s.expiration}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// SetWith is Set with per-call options, e.g. SetWith(key, "raw", expires, WithInlineSerializer(serializer.String))
+func (s *Service) SetWith(key string, value interface{}, expires time.Duration, opts ...CallOption) error {
+	if err := s.checkExpiration(expires); err != nil {
+		return err
+	}
+	o := s.callOptions(opts)
+	b, err := o.serializer.Serialize(value)
+	if err != nil {
+		return err
+	}
+	if err := s.checkSize(b); err != nil {
+		return err
+	}
+	err = s.written(o.retry.do(func() error {
+		return s.write(key, b, s.jittered(expires), "")
+	}), key)
+	return s.mirrorSet(err, key, value, expires)
+}
+
+// GetWith is Get with per-call options, the counterpart of SetWith
+func (s *Service) GetWith(key string, ptrValue interface{}, opts ...CallOption) error {
+	_, err := s.getVersion(key, ptrValue, opts)
+	return err
+}
+
+// getVersion implements GetWith and GetWithVersion
+func (s *Service) getVersion(key string, ptrValue interface{}, opts []CallOption) (int64, error) {
+	o := s.callOptions(opts)
+	cache := s.readCache()
+	if o.primary {
+		cache = s.cache
+	}
+	var b []byte
+	err := o.retry.do(func() error {
+		return cache.Get(s.cacheKey(key), &b)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if o.expiration.sliding > 0 {
+		s.cache.SetExpire(s.cacheKey(key), o.expiration.sliding)
+	}
+	var version int64
+	if s.versioned {
+		version, b = redisstore.Unversion(b)
+	}
+	return version, s.decode(o.serializer, key, b, ptrValue)
+}
+
+// SetIfChanged is Set skipping the write, and the replication traffic it causes,
+// when the stored value is already equal to value. It reports whether it wrote.
+func (s *Service) SetIfChanged(key string, value interface{}, expires time.Duration) (bool, error) {
+	if err := s.checkExpiration(expires); err != nil {
+		return false, err
+	}
+	encoded, err := s.encode(value)
+	if err != nil {
+		return false, err
+	}
+	var changed bool
+	if s.versioned {
+		_, err = s.store.SetVersioned(s.cacheKey(key), s.VersionKey(key), encoded, s.jittered(expires), "CHANGED")
+		changed = err == nil
+		if err == redisstore.ErrNotStored {
+			err = nil
+		}
+	} else {
+		changed, err = s.store.SetIfChanged(s.cacheKey(key), encoded, s.jittered(expires))
+	}
+	if changed {
+		s.written(nil, key)
+		err = s.setEncodings(key, value, expires)
+	}
+	return changed, err
+}
+
+// Heartbeat atomically sets key to value with ttl when it is absent, or only
+// extends its ttl otherwise, and reports whether this was the first heartbeat
+func (s *Service) Heartbeat(key string, value interface{}, ttl time.Duration) (bool, error) {
+	if err := s.checkExpiration(ttl); err != nil {
+		return false, err
+	}
+	encoded, err := s.encode(value)
+	if err != nil {
+		return false, err
+	}
+	created, err := s.store.Heartbeat(s.cacheKey(key), encoded, ttl)
+	if err != nil {
+		return created, err
+	}
+	if created {
+		s.written(nil, key)
+		return created, s.setEncodings(key, value, ttl)
+	}
+	s.expireEncodings(key, ttl)
+	return created, nil
+}
+
+// CompareAndSwap atomically replaces the value of key with new if it currently
+// equals old, and reports whether it did. A missing key fails the swap unless
+// WithCreateIfMissing is passed.
+func (s *Service) CompareAndSwap(key string, old, new interface{}, expires time.Duration, opts ...CallOption) (bool, error) {
+	o := s.callOptions(opts)
+	encodedOld, err := s.encode(old)
+	if err != nil {
+		return false, err
+	}
+	encodedNew, err := s.encode(new)
+	if err != nil {
+		return false, err
+	}
+	var swapped bool
+	if s.versioned {
+		swapped, err = s.store.CompareAndSwapVersioned(s.cacheKey(key), s.VersionKey(key), encodedOld, encodedNew, s.jittered(expires), o.createMissing)
+	} else {
+		swapped, err = s.store.CompareAndSwap(s.cacheKey(key), encodedOld, encodedNew, s.jittered(expires), o.createMissing)
+	}
+	if swapped && err == nil {
+		err = s.setEncodings(key, new, expires)
+	}
+	return swapped, err
+}
@@ -0,0 +1,34 @@
+package goredis
+
+import "fmt"
+
+// Do sends a command the Service does not wrap, applying the key prefix to the
+// arguments at keyArgIndexes, which must be strings. The reply is returned as is,
+// use the redigo reply helpers (redis.String, redis.Int, ...) to convert it.
+func (s *Service) Do(cmd string, keyArgIndexes []int, args ...interface{}) (interface{}, error) {
+	prefixed := make([]interface{}, len(args))
+	copy(prefixed, args)
+	for _, i := range keyArgIndexes {
+		if i < 0 || i >= len(args) {
+			return nil, fmt.Errorf("goredis: key argument index %d out of range", i)
+		}
+		key, ok := args[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("goredis: key argument %d is %T, not a string", i, args[i])
+		}
+		prefixed[i] = s.cacheKey(key)
+	}
+	return s.store.Do(cmd, prefixed...)
+}
+
+// SupportedCommands returns the lower-cased names of the commands the server
+// implements, probed once with COMMAND and cached, e.g. to check a deployment
+// serves UNLINK, GETDEL or COPY before relying on them
+func (s *Service) SupportedCommands() ([]string, error) {
+	return s.store.SupportedCommands()
+}
+
+// SupportsCommand reports whether the server implements cmd, see SupportedCommands
+func (s *Service) SupportsCommand(cmd string) (bool, error) {
+	return s.store.SupportsCommand(cmd)
+}
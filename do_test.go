@@ -0,0 +1,40 @@
+package goredis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestService_Do(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.Delete("do_key")
+	if err := s.Set("do_key", "value", time.Minute); err != nil {
+		t.FailNow()
+	}
+	if n, err := redis.Int(s.Do("STRLEN", []int{0}, "do_key")); err != nil || n != len("value") {
+		t.FailNow()
+	}
+	if _, err := s.Do("STRLEN", []int{1}, "do_key"); err == nil {
+		t.FailNow()
+	}
+}
+
+func TestService_SupportsCommand(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	if ok, err := s.SupportsCommand("GET"); err != nil || !ok {
+		t.FailNow()
+	}
+	if ok, err := s.SupportsCommand("NOSUCHCOMMAND"); err != nil || ok {
+		t.FailNow()
+	}
+	commands, err := s.SupportedCommands()
+	if err != nil || len(commands) == 0 {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,94 @@
+package goredis
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+	"github.com/owngoals/go-redis/serializer"
+)
+
+// WithEncodings makes every Set, SetWith, Add, Replace, AddMulti, SetIfChanged,
+// CompareAndSwap, Heartbeat, Copy and GetOrSet load that writes also store the
+// value encoded, untagged, in each of formats under EncodedKey. Heartbeat extends
+// their TTL, and the Delete variants, GetDel and DeleteByPrefix remove them.
+// Increment, Decrement, SetRange and the expiration setters leave them as they
+// are. WithEncodings(serializer.FormatJSON) lets clients in other languages read
+// plain JSON next to the values Go services read.
+func WithEncodings(formats ...serializer.Format) Option {
+	return func(s *Service) {
+		s.encodings = formats
+	}
+}
+
+// EncodedKey returns the Redis key holding the copy of key encoded in format,
+// e.g. "goredis:json:{prefix:key}", for clients reading it directly. It is
+// outside the prefix, so Scan and Sample never return it.
+func (s *Service) EncodedKey(key string, format serializer.Format) string {
+	return s.reservedKey(format.String(), key)
+}
+
+// GetWithEncoding reads the copy of key stored in format by WithEncodings
+func (s *Service) GetWithEncoding(key string, ptrValue interface{}, format serializer.Format) error {
+	ser, ok := serializer.ForFormat(format)
+	if !ok {
+		return fmt.Errorf("goredis: unknown format %v", format)
+	}
+	var b []byte
+	cache := s.readCache()
+	err := s.retry.do(func() error {
+		return cache.Get(s.EncodedKey(key, format), &b)
+	})
+	if err != nil {
+		return err
+	}
+	return s.decode(ser, key, b, ptrValue)
+}
+
+// setEncodings writes the copies of value configured with WithEncodings
+func (s *Service) setEncodings(key string, value interface{}, expires time.Duration) error {
+	for _, format := range s.encodings {
+		ser, ok := serializer.ForFormat(format)
+		if !ok {
+			return fmt.Errorf("goredis: unknown format %v", format)
+		}
+		b, err := ser.Serialize(value)
+		if err != nil {
+			return err
+		}
+		if err := s.cache.Set(s.EncodedKey(key, format), b, s.jittered(expires)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expireEncodings sets the TTL of the copies configured with WithEncodings
+func (s *Service) expireEncodings(key string, expires time.Duration) {
+	for _, format := range s.encodings {
+		s.cache.SetExpire(s.EncodedKey(key, format), expires)
+	}
+}
+
+// copyEncodings duplicates the copies of src configured with WithEncodings into dst
+func (s *Service) copyEncodings(src, dst string, replace bool) error {
+	for _, format := range s.encodings {
+		_, err := s.store.Copy(s.EncodedKey(src, format), s.EncodedKey(dst, format), replace)
+		if err != nil && err != redisstore.ErrCacheMiss {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteEncodings removes the copies configured with WithEncodings
+func (s *Service) deleteEncodings(keys ...string) error {
+	for _, key := range keys {
+		for _, format := range s.encodings {
+			if err := s.cache.Delete(s.EncodedKey(key, format)); err != nil && err != redisstore.ErrCacheMiss {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package goredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+	"github.com/owngoals/go-redis/serializer"
+)
+
+func TestService_WithEncodings(t *testing.T) {
+	type user struct {
+		Name string
+	}
+	store := redisstore.NewMemoryStore(redisstore.DEFAULT, 0)
+	s := NewServiceWithStore(store, testPrefix, WithEncodings(serializer.FormatJSON))
+	if err := s.Set("user", user{Name: "gopher"}, time.Minute); err != nil {
+		t.FailNow()
+	}
+	var raw []byte
+	if err := store.Get(s.EncodedKey("user", serializer.FormatJSON), &raw); err != nil || string(raw) != `{"Name":"gopher"}` {
+		t.FailNow()
+	}
+	var u user
+	if err := s.GetWithEncoding("user", &u, serializer.FormatJSON); err != nil || u.Name != "gopher" {
+		t.FailNow()
+	}
+	if err := s.Get("user", &u); err != nil || u.Name != "gopher" {
+		t.FailNow()
+	}
+	if err := s.Delete("user"); err != nil {
+		t.FailNow()
+	}
+	if err := s.GetWithEncoding("user", &u, serializer.FormatJSON); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+	if s.EncodedKey("user", serializer.FormatJSON) != "goredis:json:{"+testPrefix+":user}" {
+		t.FailNow()
+	}
+	err := s.GetOrSetContext(context.Background(), "loaded", &u, time.Minute, func(ctx context.Context) (interface{}, error) {
+		return user{Name: "loaded"}, nil
+	})
+	if err != nil {
+		t.FailNow()
+	}
+	if err := s.GetWithEncoding("loaded", &u, serializer.FormatJSON); err != nil || u.Name != "loaded" {
+		t.FailNow()
+	}
+}
+
+func TestService_WithEncodings_WritePaths(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix+"_encodings", WithEncodings(serializer.FormatJSON))
+	defer s.FlushPrefix()
+	var v string
+	if _, err := s.AddMulti(map[string]interface{}{"multi": "a"}, time.Minute); err != nil {
+		t.FailNow()
+	}
+	if err := s.GetWithEncoding("multi", &v, serializer.FormatJSON); err != nil || v != "a" {
+		t.FailNow()
+	}
+	if ok, err := s.CompareAndSwap("multi", "a", "b", time.Minute); err != nil || !ok {
+		t.FailNow()
+	}
+	if err := s.GetWithEncoding("multi", &v, serializer.FormatJSON); err != nil || v != "b" {
+		t.FailNow()
+	}
+	if ok, err := s.SetIfChanged("multi", "c", time.Minute); err != nil || !ok {
+		t.FailNow()
+	}
+	if err := s.GetWithEncoding("multi", &v, serializer.FormatJSON); err != nil || v != "c" {
+		t.FailNow()
+	}
+	keys, err := s.Sample(10)
+	if err != nil || len(keys) != 1 || keys[0] != "multi" {
+		t.FailNow()
+	}
+	if err := s.GetDel("multi", &v); err != nil {
+		t.FailNow()
+	}
+	if err := s.GetWithEncoding("multi", &v, serializer.FormatJSON); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+	if _, err := s.Heartbeat("beat", "alive", time.Minute); err != nil {
+		t.FailNow()
+	}
+	if n, err := s.FlushPrefix(); err != nil || n != 1 {
+		t.FailNow()
+	}
+	if err := s.GetWithEncoding("beat", &v, serializer.FormatJSON); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+}
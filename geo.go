@@ -0,0 +1,10 @@
+package goredis
+
+func (s *Service) GeoAdd(key string, lon, lat float64, member string) error {
+	return s.store.GeoAdd(s.cacheKey(key), lon, lat, member)
+}
+
+// GeoRadius returns the members within radius (in unit: m, km, ft or mi) of lon/lat, nearest first
+func (s *Service) GeoRadius(key string, lon, lat, radius float64, unit string) ([]string, error) {
+	return s.store.GeoRadius(s.cacheKey(key), lon, lat, radius, unit)
+}
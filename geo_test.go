@@ -0,0 +1,25 @@
+package goredis
+
+import "testing"
+
+func TestService_GeoRadius(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "poi"
+	defer s.Delete(key)
+	if err := s.GeoAdd(key, 13.361389, 38.115556, "palermo"); err != nil {
+		t.FailNow()
+	}
+	if err := s.GeoAdd(key, 15.087269, 37.502669, "catania"); err != nil {
+		t.FailNow()
+	}
+	members, err := s.GeoRadius(key, 15, 37, 100, "km")
+	if err != nil || len(members) != 1 || members[0] != "catania" {
+		t.FailNow()
+	}
+	members, err = s.GeoRadius(key, 15, 37, 200, "km")
+	if err != nil || len(members) != 2 || members[0] != "catania" {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,122 @@
+package goredis
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+// ErrNotFound is returned by a loader when the value does not exist in the
+// backend. GetOrSetWithNegativeCache caches it as a tombstone, later lookups
+// of the key return it without calling the loader.
+var ErrNotFound = errors.New("cache: not found")
+
+// tombstone is the value stored for a negatively cached key
+var tombstone = []byte("\x00goredis:tombstone")
+
+// GetOrSetContext reads key into ptrValue, calling loader and caching its result
+// on a miss. Redis commands are bounded by ctx. Concurrent misses on the same key
+// share a single loader call, which keeps running as long as at least one caller
+// is still waiting for it.
+func (s *Service) GetOrSetContext(ctx context.Context, key string, ptrValue interface{}, expires time.Duration,
+	loader func(ctx context.Context) (interface{}, error)) error {
+	return s.getOrSet(ctx, key, ptrValue, expires, 0, loader)
+}
+
+// GetOrSetWithNegativeCache is GetOrSetContext that also caches misses: when loader
+// returns ErrNotFound a tombstone is stored for negativeExpires and ErrNotFound is
+// returned, until it expires, instead of calling loader again.
+func (s *Service) GetOrSetWithNegativeCache(ctx context.Context, key string, ptrValue interface{},
+	expires, negativeExpires time.Duration, loader func(ctx context.Context) (interface{}, error)) error {
+	return s.getOrSet(ctx, key, ptrValue, expires, negativeExpires, loader)
+}
+
+// getOrSet implements the GetOrSet variants, a zero negativeExpires disables negative caching
+func (s *Service) getOrSet(ctx context.Context, key string, ptrValue interface{}, expires, negativeExpires time.Duration,
+	loader func(ctx context.Context) (interface{}, error)) error {
+	cacheKey := s.cacheKey(key)
+	var b []byte
+	err := s.cacheContext(ctx).Get(cacheKey, &b)
+	if err == nil && !bytes.Equal(b, tombstone) {
+		// an undecodable value is reloaded under MissOnDecodeError
+		if err = s.decode(s.codec(), key, s.unversion(b), ptrValue); err != redisstore.ErrCacheMiss {
+			return err
+		}
+	}
+	if err == redisstore.ErrCacheMiss {
+		b, err = s.flight.do(ctx, cacheKey, func(ctx context.Context) ([]byte, error) {
+			value, err := loader(ctx)
+			if err == ErrNotFound && negativeExpires != 0 {
+				return tombstone, s.cacheContext(ctx).Set(cacheKey, tombstone, s.jittered(negativeExpires))
+			}
+			if err != nil {
+				return nil, err
+			}
+			b, err := s.codec().Serialize(value)
+			if err != nil {
+				return nil, err
+			}
+			cs := s.WithContext(ctx)
+			if err := cs.write(key, b, s.jittered(expires), ""); err != nil {
+				return nil, err
+			}
+			return b, cs.setEncodings(key, value, expires)
+		})
+	}
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(b, tombstone) {
+		return ErrNotFound
+	}
+	return s.decode(s.codec(), key, b, ptrValue)
+}
+
+// cacheContext returns the Store bound to ctx when it supports it
+func (s *Service) cacheContext(ctx context.Context) redisstore.Store {
+	if rs, ok := s.cache.(*redisstore.RedisStore); ok {
+		return rs.WithContext(ctx)
+	}
+	return s.cache
+}
+
+// GetOrSetRefreshAhead is GetOrSetContext that also reloads the value in the
+// background once its remaining time to live drops below refreshWindow, while
+// still serving the current value, so hot keys never expire under their callers.
+// A lock in the reserved namespace, taken with SET NX PX for refreshWindow and
+// released by its token, guards the refresh so a single caller, across
+// processes, reloads at a time. It costs a PTTL round-trip per hit; a failed
+// refresh is dropped and retried by a later call. The refresh is written with Set, detached from ctx,
+// so it outlives the call and runs every Set side effect.
+func (s *Service) GetOrSetRefreshAhead(ctx context.Context, key string, ptrValue interface{}, expires,
+	refreshWindow time.Duration, loader func(ctx context.Context) (interface{}, error)) error {
+	if err := s.getOrSet(ctx, key, ptrValue, expires, 0, loader); err != nil {
+		return err
+	}
+	cacheKey := s.cacheKey(key)
+	ttl, err := s.store.WithContext(ctx).TTL(cacheKey)
+	if err != nil || ttl < 0 || ttl >= refreshWindow {
+		return nil
+	}
+	token, err := randomToken()
+	if err != nil {
+		return nil
+	}
+	lock := s.reservedKey("refresh", key)
+	if locked, err := s.store.WithContext(ctx).Lock(lock, token, refreshWindow); err != nil || !locked {
+		return nil
+	}
+	detached := s.WithContext(context.Background())
+	go func() {
+		defer detached.store.DeleteIfEqual(lock, token)
+		value, err := loader(context.Background())
+		if err != nil {
+			return
+		}
+		detached.Set(key, value, expires)
+	}()
+	return nil
+}
@@ -0,0 +1,148 @@
+package goredis
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/owngoals/go-redis/serializer"
+)
+
+func TestService_GetOrSetContext(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "loaded"
+	defer s.Delete(key)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var calls int32
+	loader := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(100 * time.Millisecond)
+		return "hello", nil
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var v string
+			if err := s.GetOrSetContext(ctx, key, &v, 1*time.Minute, loader); err != nil || v != "hello" {
+				t.Error(err, v)
+			}
+		}()
+	}
+	wg.Wait()
+	if atomic.LoadInt32(&calls) != 1 {
+		t.FailNow()
+	}
+}
+
+func TestFlightGroup_CancelledWaiter(t *testing.T) {
+	var g flightGroup
+	release := make(chan struct{})
+	fn := func(ctx context.Context) ([]byte, error) {
+		select {
+		case <-release:
+			return []byte("ok"), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := g.do(ctx, "key", fn)
+		first <- err
+	}()
+	second := make(chan []byte, 1)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		b, _ := g.do(context.Background(), "key", fn)
+		second <- b
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if err := <-first; err != context.Canceled {
+		t.Fatal(err)
+	}
+	close(release)
+	if b := <-second; string(b) != "ok" {
+		t.Fatal(string(b))
+	}
+}
+
+func TestService_GetOrSetWithNegativeCache(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "absent"
+	defer s.Delete(key)
+	var calls int
+	loader := func(ctx context.Context) (interface{}, error) {
+		calls++
+		return nil, ErrNotFound
+	}
+	var v string
+	for i := 0; i < 3; i++ {
+		err := s.GetOrSetWithNegativeCache(context.Background(), key, &v, time.Minute, 5*time.Second, loader)
+		if err != ErrNotFound {
+			t.FailNow()
+		}
+	}
+	if calls != 1 {
+		t.FailNow()
+	}
+}
+
+func TestService_GetOrSetRefreshAhead(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix, WithEncodings(serializer.FormatJSON))
+	key := "refreshed"
+	defer s.Delete(key)
+	ctx := context.Background()
+	var calls int32
+	loader := func(ctx context.Context) (interface{}, error) {
+		return int(atomic.AddInt32(&calls, 1)), nil
+	}
+	var v int
+	if err := s.GetOrSetRefreshAhead(ctx, key, &v, 2*time.Second, time.Second, loader); err != nil || v != 1 {
+		t.FailNow()
+	}
+	time.Sleep(1500 * time.Millisecond)
+	// the refresh outlives the context of the call that triggered it
+	callCtx, cancel := context.WithCancel(ctx)
+	if err := s.GetOrSetRefreshAhead(callCtx, key, &v, 2*time.Second, time.Second, loader); err != nil || v != 1 {
+		t.FailNow()
+	}
+	cancel()
+	time.Sleep(200 * time.Millisecond)
+	if err := s.Get(key, &v); err != nil || v != 2 {
+		t.FailNow()
+	}
+	if err := s.GetWithEncoding(key, &v, serializer.FormatJSON); err != nil || v != 2 {
+		t.FailNow()
+	}
+	if ttl, err := s.TTL(key); err != nil || ttl < time.Second {
+		t.FailNow()
+	}
+	if s.store.Exists(s.reservedKey("refresh", key)) {
+		t.FailNow()
+	}
+
+	// a window under a second still takes the lock
+	if err := s.Set(key, 30, 300*time.Millisecond); err != nil {
+		t.FailNow()
+	}
+	if err := s.GetOrSetRefreshAhead(ctx, key, &v, 2*time.Second, 500*time.Millisecond, loader); err != nil || v != 30 {
+		t.FailNow()
+	}
+	time.Sleep(200 * time.Millisecond)
+	if err := s.Get(key, &v); err != nil || v != 3 {
+		t.FailNow()
+	}
+}
@@ -1,5 +1,5 @@
 module github.com/owngoals/go-redis
 
-go 1.14
+go 1.18
 
 require github.com/gomodule/redigo v1.8.1
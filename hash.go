@@ -0,0 +1,68 @@
+package goredis
+
+import "reflect"
+
+func (s *Service) HSet(key, field string, value interface{}) error {
+	value, err := s.encode(value)
+	if err != nil {
+		return err
+	}
+	return s.store.HSet(s.cacheKey(key), field, value)
+}
+
+func (s *Service) HGet(key, field string, ptrValue interface{}) error {
+	return s.decodeFrom(key, ptrValue, func(b *[]byte) error {
+		return s.retry.do(func() error {
+			return s.store.HGet(s.cacheKey(key), field, b)
+		})
+	})
+}
+
+// HDel removes fields from the hash and returns how many existed. Passing no
+// fields is a no-op.
+func (s *Service) HDel(key string, fields ...string) (int, error) {
+	return s.store.HDel(s.cacheKey(key), fields...)
+}
+
+// HExists reports whether field is set in the hash, retried like HGet
+func (s *Service) HExists(key, field string) (found bool, err error) {
+	err = s.retry.do(func() error {
+		found, err = s.store.HExists(s.cacheKey(key), field)
+		return err
+	})
+	return found, err
+}
+
+// HMGet reads several fields of a hash at once. Pointers placed in out for a
+// field are deserialized into, other fields are set to their raw []byte.
+// Fields missing from the hash are removed from out.
+func (s *Service) HMGet(key string, fields []string, out map[string]interface{}) error {
+	raw := make(map[string]interface{}, len(fields))
+	err := s.retry.do(func() error {
+		return s.store.HMGet(s.cacheKey(key), fields, raw)
+	})
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		b, ok := raw[field]
+		if !ok {
+			delete(out, field)
+			continue
+		}
+		if ptr := out[field]; ptr != nil && reflect.ValueOf(ptr).Kind() == reflect.Ptr {
+			if err := s.decode(s.codec(), key, b.([]byte), ptr); err != nil {
+				return err
+			}
+			continue
+		}
+		out[field] = b
+	}
+	return nil
+}
+
+// HScan calls fn for each field of the hash whose name matches pattern, with its
+// raw value, without loading the whole hash like HGETALL would
+func (s *Service) HScan(key, pattern string, fn func(field string, value []byte) error) error {
+	return s.store.HScan(s.cacheKey(key), pattern, fn)
+}
@@ -0,0 +1,107 @@
+package goredis
+
+import (
+	"testing"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+func TestService_HMGet(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "session_hash"
+	defer s.Delete(key)
+	if err := s.HSet(key, "user", "hello"); err != nil {
+		t.FailNow()
+	}
+	if err := s.HSet(key, "visits", 3); err != nil {
+		t.FailNow()
+	}
+	var user string
+	var visits int
+	out := map[string]interface{}{"user": &user, "visits": &visits}
+	if err := s.HMGet(key, []string{"user", "visits", "missing"}, out); err != nil {
+		t.FailNow()
+	}
+	if user != "hello" || visits != 3 {
+		t.FailNow()
+	}
+	if _, ok := out["missing"]; ok {
+		t.FailNow()
+	}
+}
+
+func TestService_HDel(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "session_fields"
+	defer s.Delete(key)
+	if err := s.HSet(key, "stale", 1); err != nil {
+		t.FailNow()
+	}
+	if found, err := s.HExists(key, "stale"); err != nil || !found {
+		t.FailNow()
+	}
+	if n, err := s.HDel(key, "stale", "missing"); err != nil || n != 1 {
+		t.FailNow()
+	}
+	if found, err := s.HExists(key, "stale"); err != nil || found {
+		t.FailNow()
+	}
+}
+
+func TestService_HScan(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "session_scan"
+	defer s.Delete(key)
+	for _, field := range []string{"user:1", "user:2", "visits"} {
+		if err := s.HSet(key, field, 1); err != nil {
+			t.FailNow()
+		}
+	}
+	var fields []string
+	err := s.HScan(key, "user:*", func(field string, value []byte) error {
+		if string(value) != "1" {
+			t.FailNow()
+		}
+		fields = append(fields, field)
+		return nil
+	})
+	if err != nil || len(fields) != 2 {
+		t.FailNow()
+	}
+}
+
+func TestService_HGetAllStruct(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	type session struct {
+		User    string `redis:"user"`
+		Visits  int    `redis:"visits"`
+		Admin   bool
+		Scratch string `redis:"-"`
+	}
+	key := "session_struct"
+	defer s.Delete(key)
+	var out session
+	if err := s.HGetAllStruct(key, &out); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+	if err := s.HSetStruct(key, session{User: "gopher", Visits: 3, Admin: true, Scratch: "x"}); err != nil {
+		t.FailNow()
+	}
+	if found, err := s.HExists(key, "Scratch"); err != nil || found {
+		t.FailNow()
+	}
+	if err := s.HGetAllStruct(key, &out); err != nil {
+		t.FailNow()
+	}
+	if out.User != "gopher" || out.Visits != 3 || !out.Admin || out.Scratch != "" {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,78 @@
+package goredis
+
+import (
+	"errors"
+	"reflect"
+)
+
+var errNotStructPtr = errors.New("goredis: value must be a pointer to a struct")
+
+// HGetAllStruct reads the hash at key into the struct ptrStruct points to. Each
+// exported field is read from the hash field named by its redis tag, or by its
+// name when untagged, and decoded with the configured serializer; fields tagged
+// redis:"-" and hash fields without a struct field are ignored. It returns
+// redisstore.ErrCacheMiss when the hash does not exist.
+func (s *Service) HGetAllStruct(key string, ptrStruct interface{}) error {
+	v := reflect.ValueOf(ptrStruct)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errNotStructPtr
+	}
+	var fields map[string][]byte
+	err := s.retry.do(func() (err error) {
+		fields, err = s.store.HGetAll(s.cacheKey(key))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return eachHashField(v.Elem(), func(name string, field reflect.Value) error {
+		b, ok := fields[name]
+		if !ok {
+			return nil
+		}
+		return s.decode(s.codec(), key, b, field.Addr().Interface())
+	})
+}
+
+// HSetStruct writes the exported fields of value, a struct or a pointer to one,
+// to the hash at key in a single HSET, mapped as HGetAllStruct reads them
+func (s *Service) HSetStruct(key string, value interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(value))
+	if v.Kind() != reflect.Struct {
+		return errNotStructPtr
+	}
+	fields := make(map[string][]byte, v.NumField())
+	err := eachHashField(v, func(name string, field reflect.Value) error {
+		b, err := s.codec().Serialize(field.Interface())
+		fields[name] = b
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return s.store.HSetMulti(s.cacheKey(key), fields)
+}
+
+// eachHashField calls fn with the hash field name of every exported field of v
+func eachHashField(v reflect.Value, fn func(name string, field reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("redis"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		if err := fn(name, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
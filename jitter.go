@@ -0,0 +1,38 @@
+package goredis
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+// ttlJitter adds a random offset to positive expirations
+type ttlJitter struct {
+	max time.Duration
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func (j *ttlJitter) apply(expires time.Duration) time.Duration {
+	if j == nil || j.max <= 0 || expires <= 0 {
+		return expires
+	}
+	j.mu.Lock()
+	offset := time.Duration(j.rnd.Int63n(int64(j.max) + 1))
+	j.mu.Unlock()
+	return expires + offset
+}
+
+// jittered applies WithTTLJitter to expires, resolving DEFAULT to the default
+// expiration of the store first so that those writes are spread too
+func (s *Service) jittered(expires time.Duration) time.Duration {
+	if s.jitter == nil {
+		return expires
+	}
+	if expires == redisstore.DEFAULT {
+		expires = s.defaultExpiration()
+	}
+	return s.jitter.apply(expires)
+}
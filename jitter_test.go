@@ -0,0 +1,35 @@
+package goredis
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+func TestTTLJitter(t *testing.T) {
+	s := &Service{}
+	WithTTLJitterSource(10*time.Second, rand.NewSource(1))(s)
+	for i := 0; i < 100; i++ {
+		d := s.jitter.apply(time.Minute)
+		if d < time.Minute || d > time.Minute+10*time.Second {
+			t.Fatal(d)
+		}
+	}
+	if s.jitter.apply(redisstore.FOREVER) != redisstore.FOREVER || s.jitter.apply(redisstore.DEFAULT) != redisstore.DEFAULT {
+		t.FailNow()
+	}
+	var none *ttlJitter
+	if none.apply(time.Minute) != time.Minute {
+		t.FailNow()
+	}
+
+	s.cache = redisstore.NewRedisCacheWithPool(nil, time.Minute)
+	if d := s.jittered(redisstore.DEFAULT); d < time.Minute || d > time.Minute+10*time.Second {
+		t.Fatal(d)
+	}
+	if s.jittered(redisstore.FOREVER) != redisstore.FOREVER {
+		t.FailNow()
+	}
+}
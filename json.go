@@ -0,0 +1,13 @@
+package goredis
+
+// JSONGet reads the value at path of a RedisJSON document into ptrValue with
+// encoding/json. Returns redisstore.ErrNotSupport without the RedisJSON module.
+func (s *Service) JSONGet(key, path string, ptrValue interface{}) error {
+	return s.store.JSONGet(s.cacheKey(key), path, ptrValue)
+}
+
+// JSONSet writes value, encoded with encoding/json, at path of a RedisJSON
+// document. Use the root path "$" to create the document.
+func (s *Service) JSONSet(key, path string, value interface{}) error {
+	return s.store.JSONSet(s.cacheKey(key), path, value)
+}
@@ -0,0 +1,30 @@
+package goredis
+
+import (
+	"testing"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+func TestService_JSONGet(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "json_doc"
+	defer s.Delete(key)
+	doc := map[string]interface{}{"name": "a", "tags": []string{"x", "y"}}
+	err := s.JSONSet(key, "$", doc)
+	if err == redisstore.ErrNotSupport {
+		t.Skip("RedisJSON module not loaded")
+	}
+	if err != nil {
+		t.FailNow()
+	}
+	var name string
+	if err := s.JSONGet(key, ".name", &name); err != nil || name != "a" {
+		t.FailNow()
+	}
+	if err := s.JSONGet("json_missing", ".name", &name); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+}
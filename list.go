@@ -0,0 +1,131 @@
+package goredis
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+func (s *Service) LPush(key string, values ...interface{}) (int, error) {
+	values, err := s.encodeAll(values)
+	if err != nil {
+		return 0, err
+	}
+	return s.store.LPush(s.cacheKey(key), values...)
+}
+
+func (s *Service) RPush(key string, values ...interface{}) (int, error) {
+	values, err := s.encodeAll(values)
+	if err != nil {
+		return 0, err
+	}
+	return s.store.RPush(s.cacheKey(key), values...)
+}
+
+func (s *Service) LPop(key string, ptrValue interface{}) error {
+	return s.decodeFrom(key, ptrValue, func(b *[]byte) error {
+		return s.store.LPop(s.cacheKey(key), b)
+	})
+}
+
+func (s *Service) RPop(key string, ptrValue interface{}) error {
+	return s.decodeFrom(key, ptrValue, func(b *[]byte) error {
+		return s.store.RPop(s.cacheKey(key), b)
+	})
+}
+
+// BLPop waits up to timeout for an element to pop, zero blocks indefinitely.
+// Returns redisstore.ErrCacheMiss when the timeout expires with the list still empty.
+func (s *Service) BLPop(key string, timeout time.Duration, ptrValue interface{}) error {
+	return s.decodeFrom(key, ptrValue, func(b *[]byte) error {
+		return s.store.BLPop(s.cacheKey(key), timeout, b)
+	})
+}
+
+// BRPop is the blocking variant of RPop, see BLPop
+func (s *Service) BRPop(key string, timeout time.Duration, ptrValue interface{}) error {
+	return s.decodeFrom(key, ptrValue, func(b *[]byte) error {
+		return s.store.BRPop(s.cacheKey(key), timeout, b)
+	})
+}
+
+// BLMPop waits up to timeout, zero blocks indefinitely, for an element to pop
+// from the first non-empty list of keys, e.g. queues by descending priority, and
+// returns the key it came from. It pops from the head when fromLeft, the tail
+// otherwise (Redis 7+). Returns redisstore.ErrCacheMiss when the timeout expires.
+func (s *Service) BLMPop(timeout time.Duration, keys []string, fromLeft bool, ptrValue interface{}) (string, error) {
+	var b []byte
+	key, err := s.store.BLMPop(timeout, s.cacheKeys(keys), fromLeft, &b)
+	if err != nil {
+		return "", err
+	}
+	key = s.stripKey(key)
+	return key, s.decode(s.codec(), key, b, ptrValue)
+}
+
+// LMPop pops up to count items from the first non-empty list of keys into out,
+// a pointer to a slice of T or *T, to process a queue in chunks, and returns the
+// key they came from. It pops from the head when fromLeft, the tail otherwise
+// (Redis 7+). When all lists are empty out is set to an empty slice and the
+// returned key is "".
+func (s *Service) LMPop(keys []string, count int, fromLeft bool, out interface{}) (string, error) {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return "", errNotSlicePtr
+	}
+	key, items, err := s.store.LMPop(s.cacheKeys(keys), count, fromLeft)
+	if err == redisstore.ErrCacheMiss {
+		v.Elem().Set(reflect.MakeSlice(v.Elem().Type(), 0, 0))
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return s.stripKey(key), decodeSlice(s.codec(), items, v)
+}
+
+// ReclaimExpired returns overdue jobs for retry: members of the sorted set
+// inflightKey, scored with the Unix milliseconds their processing lock expires,
+// whose score is not after now move to the tail of the list pendingKey, popped
+// next by RPop. Jobs move in atomic batches. It returns how many jobs it moved.
+func (s *Service) ReclaimExpired(inflightKey, pendingKey string, now time.Time) (int, error) {
+	return s.store.ReclaimExpired(s.cacheKey(inflightKey), s.cacheKey(pendingKey), now.UnixNano()/int64(time.Millisecond))
+}
+
+func (s *Service) LLen(key string) (int, error) {
+	return s.store.LLen(s.cacheKey(key))
+}
+
+// LPos returns the index of the first element equal to value, redisstore.ErrCacheMiss
+// when the list does not contain it. value is serialized like LPush does.
+func (s *Service) LPos(key string, value interface{}) (int, error) {
+	value, err := s.encode(value)
+	if err != nil {
+		return 0, err
+	}
+	return s.store.LPos(s.cacheKey(key), value)
+}
+
+// LPosN returns the indexes of up to count elements equal to value, 0 for all,
+// skipping to the rank-th match first (1 is the first, -1 the last one)
+func (s *Service) LPosN(key string, value interface{}, rank, count int) ([]int, error) {
+	value, err := s.encode(value)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.LPosN(s.cacheKey(key), value, rank, count)
+}
+
+func (s *Service) LTrim(key string, start, stop int) error {
+	return s.store.LTrim(s.cacheKey(key), start, stop)
+}
+
+// PushCapped prepends value and caps the list at its max newest elements atomically
+func (s *Service) PushCapped(key string, value interface{}, max int) error {
+	value, err := s.encode(value)
+	if err != nil {
+		return err
+	}
+	return s.store.PushCapped(s.cacheKey(key), value, max)
+}
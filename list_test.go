@@ -0,0 +1,178 @@
+package goredis
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+func TestService_LPop(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "queue"
+	defer s.Delete(key)
+	if _, err := s.RPush(key, "a", "b"); err != nil {
+		t.FailNow()
+	}
+	var v string
+	if err := s.LPop(key, &v); err != nil || v != "a" {
+		t.FailNow()
+	}
+	if err := s.RPop(key, &v); err != nil || v != "b" {
+		t.FailNow()
+	}
+	if err := s.LPop(key, &v); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+	if err := s.BLPop(key, 1*time.Second, &v); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+}
+
+func TestService_PushCapped(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "activity"
+	defer s.Delete(key)
+	for i := 0; i < 5; i++ {
+		if err := s.PushCapped(key, i, 3); err != nil {
+			t.FailNow()
+		}
+	}
+	if n, err := s.LLen(key); err != nil || n != 3 {
+		t.FailNow()
+	}
+	var v int
+	if err := s.LPop(key, &v); err != nil || v != 4 {
+		t.FailNow()
+	}
+	if err := s.PushCapped(key, 5, 0); err == nil || err == redisstore.ErrNotStored {
+		t.FailNow()
+	}
+	if err := s.Set(key, "scalar", time.Minute); err != nil {
+		t.FailNow()
+	}
+	if err := s.PushCapped(key, 6, 3); !errors.Is(err, redisstore.ErrWrongType) {
+		t.FailNow()
+	}
+}
+
+func TestService_LPos(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "priorities"
+	defer s.Delete(key)
+	if _, err := s.RPush(key, "a", "b", "a", "c", "a"); err != nil {
+		t.FailNow()
+	}
+	if i, err := s.LPos(key, "c"); err != nil || i != 3 {
+		t.FailNow()
+	}
+	if _, err := s.LPos(key, "z"); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+	if idx, err := s.LPosN(key, "a", 2, 0); err != nil || len(idx) != 2 || idx[0] != 2 || idx[1] != 4 {
+		t.FailNow()
+	}
+	if idx, err := s.LPosN(key, "a", -1, 1); err != nil || len(idx) != 1 || idx[0] != 4 {
+		t.FailNow()
+	}
+}
+
+func TestService_BLMPop(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	keys := []string{"queue:high", "queue:low"}
+	defer s.Delete(keys[0])
+	defer s.Delete(keys[1])
+	if _, err := s.RPush(keys[1], "a", "b"); err != nil {
+		t.FailNow()
+	}
+	var v string
+	if key, err := s.BLMPop(time.Second, keys, false, &v); err != nil || key != keys[1] || v != "b" {
+		t.FailNow()
+	}
+	if _, err := s.RPush(keys[0], "c"); err != nil {
+		t.FailNow()
+	}
+	if key, err := s.BLMPop(time.Second, keys, true, &v); err != nil || key != keys[0] || v != "c" {
+		t.FailNow()
+	}
+	if err := s.LPop(keys[1], &v); err != nil {
+		t.FailNow()
+	}
+	if _, err := s.BLMPop(time.Second, keys, true, &v); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+}
+
+func TestService_ReclaimExpired(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	inflight, pending := "jobs:inflight", "jobs:pending"
+	defer s.Delete(inflight)
+	defer s.Delete(pending)
+	now := time.Now()
+	ms := func(t time.Time) float64 { return float64(t.UnixNano() / int64(time.Millisecond)) }
+	if _, err := s.ZAdd(inflight, ms(now.Add(-time.Minute)), "overdue"); err != nil {
+		t.FailNow()
+	}
+	if _, err := s.ZAdd(inflight, ms(now.Add(time.Minute)), "running"); err != nil {
+		t.FailNow()
+	}
+	if n, err := s.ReclaimExpired(inflight, pending, now); err != nil || n != 1 {
+		t.FailNow()
+	}
+	var v string
+	if err := s.RPop(pending, &v); err != nil || v != "overdue" {
+		t.FailNow()
+	}
+	if n, err := s.ReclaimExpired(inflight, pending, now); err != nil || n != 0 {
+		t.FailNow()
+	}
+	// more overdue jobs than a single batch
+	for i := 0; i < 250; i++ {
+		if _, err := s.ZAdd(inflight, ms(now.Add(-time.Minute)), "job"+strconv.Itoa(i)); err != nil {
+			t.FailNow()
+		}
+	}
+	if n, err := s.ReclaimExpired(inflight, pending, now); err != nil || n != 250 {
+		t.FailNow()
+	}
+	if n, err := s.LLen(pending); err != nil || n != 250 {
+		t.FailNow()
+	}
+}
+
+func TestService_LMPop(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	keys := []string{"batch:high", "batch:low"}
+	defer s.Delete(keys[0])
+	defer s.Delete(keys[1])
+	if _, err := s.RPush(keys[1], "a", "b", "c"); err != nil {
+		t.FailNow()
+	}
+	var items []string
+	if key, err := s.LMPop(keys, 2, true, &items); err != nil || key != keys[1] || len(items) != 2 || items[0] != "a" || items[1] != "b" {
+		t.FailNow()
+	}
+	if key, err := s.LMPop(keys, 2, true, &items); err != nil || key != keys[1] || len(items) != 1 || items[0] != "c" {
+		t.FailNow()
+	}
+	if key, err := s.LMPop(keys, 2, true, &items); err != nil || key != "" || len(items) != 0 {
+		t.FailNow()
+	}
+	if _, err := s.LMPop(keys, 2, true, items); err != errNotSlicePtr {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,80 @@
+package goredis
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/owngoals/go-redis/serializer"
+)
+
+var errNotSlicePtr = errors.New("goredis: out must be a pointer to a slice")
+
+// GetMulti reads keys with a single MGET and returns their values in stored form,
+// keyed by the keys as passed. Decode them with the configured serializer,
+// serializer.Default unless WithSerializer is set. Missing keys are left out, or
+// mapped to nil with WithMisses; stored values are never nil.
+func (s *Service) GetMulti(keys []string, opts ...CallOption) (map[string][]byte, error) {
+	o := s.callOptions(opts)
+	var items [][]byte
+	err := o.retry.do(func() (err error) {
+		items, err = s.store.MGet(s.cacheKeys(keys)...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string][]byte, len(items))
+	for i, item := range items {
+		if item != nil || o.misses {
+			values[keys[i]] = s.unversion(item)
+		}
+	}
+	return values, nil
+}
+
+// GetAll reads keys with a single MGET into out, a pointer to a slice of T or *T.
+// The slice is resized to len(keys) and element i holds the value of keys[i];
+// missing keys are left as the zero value (nil for *T).
+func (s *Service) GetAll(keys []string, out interface{}) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return errNotSlicePtr
+	}
+	var items [][]byte
+	err := s.retry.do(func() (err error) {
+		items, err = s.store.MGet(s.cacheKeys(keys)...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for i, item := range items {
+		items[i] = s.unversion(item)
+	}
+	return decodeSlice(s.codec(), items, v)
+}
+
+// decodeSlice sets the slice v points to, of T or *T, to items decoded with
+// ser, leaving nil items as the zero value
+func decodeSlice(ser serializer.Serializer, items [][]byte, v reflect.Value) error {
+	sliceType := v.Elem().Type()
+	elemType := sliceType.Elem()
+	slice := reflect.MakeSlice(sliceType, len(items), len(items))
+	for i, item := range items {
+		if item == nil {
+			continue
+		}
+		ptr := slice.Index(i).Addr()
+		if elemType.Kind() == reflect.Ptr {
+			ptr = reflect.New(elemType.Elem())
+		}
+		if err := ser.Deserialize(item, ptr.Interface()); err != nil {
+			return err
+		}
+		if elemType.Kind() == reflect.Ptr {
+			slice.Index(i).Set(ptr)
+		}
+	}
+	v.Elem().Set(slice)
+	return nil
+}
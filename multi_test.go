@@ -0,0 +1,55 @@
+package goredis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestService_GetAll(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.DeleteMulti("all_a", "all_c")
+	if err := s.Set("all_a", "a", time.Minute); err != nil {
+		t.FailNow()
+	}
+	if err := s.Set("all_c", "c", time.Minute); err != nil {
+		t.FailNow()
+	}
+	var values []string
+	if err := s.GetAll([]string{"all_a", "all_b", "all_c"}, &values); err != nil {
+		t.FailNow()
+	}
+	if len(values) != 3 || values[0] != "a" || values[1] != "" || values[2] != "c" {
+		t.FailNow()
+	}
+	var ptrs []*string
+	if err := s.GetAll([]string{"all_a", "all_b"}, &ptrs); err != nil {
+		t.FailNow()
+	}
+	if len(ptrs) != 2 || *ptrs[0] != "a" || ptrs[1] != nil {
+		t.FailNow()
+	}
+}
+
+func TestService_GetMulti(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.DeleteMulti("multi_a", "multi_empty")
+	if err := s.Set("multi_a", []byte("a"), time.Minute); err != nil {
+		t.FailNow()
+	}
+	if err := s.Set("multi_empty", []byte{}, time.Minute); err != nil {
+		t.FailNow()
+	}
+	keys := []string{"multi_a", "multi_empty", "multi_missing"}
+	values, err := s.GetMulti(keys)
+	if err != nil || len(values) != 2 || string(values["multi_a"]) != "a" || values["multi_empty"] == nil {
+		t.FailNow()
+	}
+	values, err = s.GetMulti(keys, WithMisses())
+	if err != nil || len(values) != 3 || values["multi_missing"] != nil {
+		t.FailNow()
+	}
+}
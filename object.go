@@ -0,0 +1,120 @@
+package goredis
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+// KeyFreq is a key and its LFU access frequency counter
+type KeyFreq struct {
+	Key  string
+	Freq int64
+}
+
+// ObjectFreq returns the LFU access frequency of key, redisstore.ErrNotLFU when
+// the server does not run an LFU maxmemory-policy
+func (s *Service) ObjectFreq(key string) (int64, error) {
+	return s.store.ObjectFreq(s.cacheKey(key))
+}
+
+// ObjectRefCount returns the reference count of the value of key, redisstore.ErrCacheMiss
+// when it does not exist. Values held in the server's shared integer pool report
+// a very large count (INT_MAX) instead of 1.
+func (s *Service) ObjectRefCount(key string) (int64, error) {
+	return s.store.ObjectRefCount(s.cacheKey(key))
+}
+
+// ObjectIdleTime returns how long key has not been read or written,
+// redisstore.ErrCacheMiss when it does not exist
+func (s *Service) ObjectIdleTime(key string) (time.Duration, error) {
+	return s.store.ObjectIdleTime(s.cacheKey(key))
+}
+
+// MarkAccessed counts as an access to keys for eviction without reading them,
+// and returns how many exist
+func (s *Service) MarkAccessed(keys ...string) (int, error) {
+	return s.store.MarkAccessed(s.cacheKeys(keys)...)
+}
+
+// SetImportant is Set that then marks key accessed every interval until ctx is
+// done or key is gone, so an allkeys-lru or allkeys-lfu policy evicts colder
+// keys first. It only biases eviction: to exempt keys from it, run a volatile-*
+// policy and write them with redisstore.FOREVER. interval must be positive.
+func (s *Service) SetImportant(ctx context.Context, key string, value interface{}, expires, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("goredis: non-positive interval %v", interval)
+	}
+	if err := s.Set(key, value, expires); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if n, err := s.MarkAccessed(key); err == nil && n == 0 {
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Inspect returns the TTL, type and memory usage of key in one round-trip,
+// redisstore.ErrCacheMiss when it does not exist
+func (s *Service) Inspect(key string) (redisstore.KeyInfo, error) {
+	return s.store.Inspect(s.cacheKey(key))
+}
+
+// LeastFrequentlyUsed scans the keys under the prefix matching pattern and returns
+// the n with the lowest access frequency, least used first. It issues one
+// OBJECT FREQ per key, so it is meant for offline tuning rather than hot paths,
+// and only keeps the n least used keys seen so far in memory.
+func (s *Service) LeastFrequentlyUsed(pattern string, n int) ([]KeyFreq, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("goredis: negative key count %d", n)
+	}
+	keys := make(freqHeap, 0, n)
+	err := s.store.ScanFreq(escapeGlob(s.prefix)+":"+pattern, func(key string, freq int64) error {
+		switch {
+		case len(keys) < n:
+			heap.Push(&keys, KeyFreq{Key: s.stripKey(key), Freq: freq})
+		case n > 0 && freq < keys[0].Freq:
+			keys[0] = KeyFreq{Key: s.stripKey(key), Freq: freq}
+			heap.Fix(&keys, 0)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Freq < keys[j].Freq
+	})
+	return keys, nil
+}
+
+// freqHeap is a max-heap of keys by frequency, the most used on top so it is
+// the one replaced when a less used key is found
+type freqHeap []KeyFreq
+
+func (h freqHeap) Len() int            { return len(h) }
+func (h freqHeap) Less(i, j int) bool  { return h[i].Freq > h[j].Freq }
+func (h freqHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *freqHeap) Push(x interface{}) { *h = append(*h, x.(KeyFreq)) }
+
+func (h *freqHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
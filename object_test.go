@@ -0,0 +1,125 @@
+package goredis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+func TestService_ObjectRefCount(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.Delete("refcount")
+	if err := s.Set("refcount", 42, time.Minute); err != nil {
+		t.FailNow()
+	}
+	if n, err := s.ObjectRefCount("refcount"); err != nil || n < 1 {
+		t.FailNow()
+	}
+	if _, err := s.ObjectRefCount("refcount_missing"); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+}
+
+func TestService_Inspect(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.Delete("inspect")
+	if err := s.Set("inspect", "value", time.Minute); err != nil {
+		t.FailNow()
+	}
+	info, err := s.Inspect("inspect")
+	if err != nil || info.Type != "string" || info.Size <= 0 || info.TTL <= 0 || info.TTL > time.Minute {
+		t.FailNow()
+	}
+	if _, err := s.Inspect("inspect_missing"); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+}
+
+func TestService_SetImportant(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.DeleteMulti("important", "unimportant")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := s.SetImportant(ctx, "important", 1, time.Minute, 0); err == nil || s.Exists("important") {
+		t.FailNow()
+	}
+	if err := s.SetImportant(ctx, "important", 1, time.Minute, 100*time.Millisecond); err != nil {
+		t.FailNow()
+	}
+	if err := s.Set("unimportant", 1, time.Minute); err != nil {
+		t.FailNow()
+	}
+	time.Sleep(2500 * time.Millisecond)
+	if idle, err := s.ObjectIdleTime("important"); err != nil || idle >= time.Second {
+		t.FailNow()
+	}
+	if idle, err := s.ObjectIdleTime("unimportant"); err != nil || idle < 2*time.Second {
+		t.FailNow()
+	}
+}
+
+func TestService_LeastFrequentlyUsed(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword, WithMaxActive(1, true))
+	defer p.Close()
+	s := NewService(p, testPrefix+"_lfu", WithHashTag(func(key string) string {
+		return "lfu"
+	}))
+	if _, err := s.LeastFrequentlyUsed("*", -1); err == nil {
+		t.FailNow()
+	}
+	defer s.FlushPrefix()
+	for _, key := range []string{"a", "b"} {
+		if err := s.Set(key, key, time.Minute); err != nil {
+			t.FailNow()
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	keys, err := s.WithContext(ctx).LeastFrequentlyUsed("*", 1)
+	if err == redisstore.ErrNotLFU {
+		return
+	}
+	if err != nil || len(keys) != 1 || (keys[0].Key != "a" && keys[0].Key != "b") {
+		t.FailNow()
+	}
+}
+
+func TestService_LeastFrequentlyUsed_Bounded(t *testing.T) {
+	p := &redis.Pool{Dial: dialUnresponsive}
+	defer p.Close()
+	freqs := []int64{5, 3, 8, 1, 9, 2, 7, 0, 6, 4}
+	s := NewService(p, testPrefix, WithInterceptor(func(cmd string, args []interface{},
+		do func() (interface{}, error)) (interface{}, error) {
+		if cmd == "SCAN" {
+			keys := make([]interface{}, len(freqs))
+			for i := range freqs {
+				keys[i] = []byte(fmt.Sprintf("%s:k%d", testPrefix, i))
+			}
+			return []interface{}{[]byte("0"), keys}, nil
+		}
+		var i int
+		fmt.Sscanf(args[1].(string), testPrefix+":k%d", &i)
+		return freqs[i], nil
+	}))
+	keys, err := s.LeastFrequentlyUsed("*", 3)
+	if err != nil || len(keys) != 3 || keys[0] != (KeyFreq{"k7", 0}) || keys[1] != (KeyFreq{"k3", 1}) ||
+		keys[2] != (KeyFreq{"k5", 2}) {
+		t.Fatal(keys, err)
+	}
+	if keys, err := s.LeastFrequentlyUsed("*", 0); err != nil || len(keys) != 0 {
+		t.FailNow()
+	}
+	if keys, err := s.LeastFrequentlyUsed("*", 20); err != nil || len(keys) != len(freqs) || keys[9].Freq != 9 {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,301 @@
+package goredis
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/owngoals/go-redis/redisstore"
+	"github.com/owngoals/go-redis/serializer"
+)
+
+// Option configures a Service
+type Option func(*Service)
+
+// WithKeyHasher transforms every key before the prefix is applied, e.g. to
+// shorten very long keys. It is applied on reads and writes alike.
+func WithKeyHasher(hasher func(string) string) Option {
+	return func(s *Service) {
+		s.keyHasher = hasher
+	}
+}
+
+// WithHashTag inserts the hash tag "{tag}" returned by tag for a key between the
+// prefix and the key, so keys sharing a tag, e.g. a tenant id, map to the same
+// Redis Cluster slot and can be used together in multi-key commands. An empty
+// tag leaves the key untagged. Scan patterns and DeleteByPrefix match the tagged
+// form "{tag}:key", while Scan yields keys with the tag stripped.
+//
+// All keys of a tag live on a single node: a tag owning a large share of the
+// keys or of the traffic unbalances the cluster, so prefer fine-grained tags.
+func WithHashTag(tag func(key string) string) Option {
+	return func(s *Service) {
+		s.hashTag = tag
+	}
+}
+
+// HexKeyEncoder hex encodes a key. Use it with WithKeyHasher when keys may contain
+// the ":" separator or arbitrary binary data, e.g. string(id) for an id []byte.
+func HexKeyEncoder(key string) string {
+	return hex.EncodeToString([]byte(key))
+}
+
+// Base64KeyEncoder is HexKeyEncoder using unpadded URL-safe base64, for shorter keys
+func Base64KeyEncoder(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// SHA256KeyHasher hashes a key to its hex encoded sha256 sum
+func SHA256KeyHasher(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// WithPoolDB declares the database the pool connections are dialed with, 0 by
+// default. Service.WithDB switches connections back to it after use.
+func WithPoolDB(db int) Option {
+	return func(s *Service) {
+		s.poolDB = db
+	}
+}
+
+// WithTTLJitter adds a random 0..max offset to every positive expiration written,
+// so keys warmed together do not expire together. DEFAULT is jittered as the
+// default expiration of the store, FOREVER is left alone.
+func WithTTLJitter(max time.Duration) Option {
+	return WithTTLJitterSource(max, rand.NewSource(time.Now().UnixNano()))
+}
+
+// WithTTLJitterSource is WithTTLJitter drawing offsets from src, for reproducible tests
+func WithTTLJitterSource(max time.Duration, src rand.Source) Option {
+	return func(s *Service) {
+		s.jitter = &ttlJitter{max: max, rnd: rand.New(src)}
+	}
+}
+
+// NilPolicy decides what Set does with a nil value
+type NilPolicy int
+
+const (
+	// NilReject makes Set return redisstore.ErrNilValue, the default
+	NilReject NilPolicy = iota
+	// NilDelete makes Set delete the key, a following Get returns redisstore.ErrCacheMiss
+	NilDelete
+)
+
+// WithNilPolicy sets how Set handles nil values. Add, Replace and AddMulti
+// always reject them with redisstore.ErrNilValue.
+func WithNilPolicy(policy NilPolicy) Option {
+	return func(s *Service) {
+		s.nilPolicy = policy
+	}
+}
+
+// ExpirationPolicy decides whether reading a value extends its expiration
+type ExpirationPolicy struct {
+	sliding time.Duration
+}
+
+// AbsoluteExpiration leaves expirations alone on reads: values expire the
+// duration they were written with after their last write. It is the default.
+var AbsoluteExpiration = ExpirationPolicy{}
+
+// SlidingExpiration resets the expiration of a value to ttl every time Get,
+// GetWith or GetWithVersion finds it, so values read often stay cached. Each such
+// hit costs an extra round-trip to the primary, after the read, to update the
+// expiration.
+func SlidingExpiration(ttl time.Duration) ExpirationPolicy {
+	return ExpirationPolicy{sliding: ttl}
+}
+
+// WithExpiration sets the expiration policy of reads, see WithExpirationPolicy
+// to override it for a single call
+func WithExpiration(policy ExpirationPolicy) Option {
+	return func(s *Service) {
+		s.expiration = policy
+	}
+}
+
+// WithCircuitBreaker fails operations fast with redisstore.ErrCircuitOpen for
+// cooldown after threshold consecutive connection failures, then probes Redis
+// with a single operation before resuming. Misses and server errors do not count.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(s *Service) {
+		s.setStore(s.store.WithCircuitBreaker(redisstore.NewCircuitBreaker(threshold, cooldown)))
+	}
+}
+
+// WithBorrowTimeout fails operations with redisstore.ErrPoolExhausted when no
+// connection could be borrowed within timeout, see WithMaxActive
+func WithBorrowTimeout(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.setStore(s.store.WithBorrowTimeout(timeout))
+	}
+}
+
+// WithInterceptor runs every Redis command through i, e.g. to inject latency or
+// errors in tests of timeout and retry handling without a misbehaving server
+func WithInterceptor(i redisstore.Interceptor) Option {
+	return func(s *Service) {
+		s.setStore(s.store.WithInterceptor(i))
+	}
+}
+
+// WithRetry retries idempotent operations failing with a network error under policy
+func WithRetry(policy RetryPolicy) Option {
+	return func(s *Service) {
+		s.retry = policy
+	}
+}
+
+// WithInvalidationBus publishes on channel the key of every Set, SetWith, Add,
+// Replace and Delete variant that succeeds, see Service.InvalidationBus
+func WithInvalidationBus(channel string) Option {
+	return func(s *Service) {
+		s.invalidation = channel
+	}
+}
+
+// WithSerializer encodes values with ser instead of the default gob based
+// encoding, e.g. serializer.Tagged(serializer.FormatJSON) to migrate to JSON.
+// Counters only work with serializers that store integers in decimal, as
+// Default, JSON, Tagged and Compressed do; with others Increment and Decrement
+// fail on values written by Set.
+func WithSerializer(ser serializer.Serializer) Option {
+	return func(s *Service) {
+		s.serializer = ser
+	}
+}
+
+// WithCompressor compresses values of at least minSize bytes with c once
+// serialized. Values written uncompressed or with another registered compressor
+// remain readable, so the codec and threshold can be changed on live data.
+// It wraps the serializer configured by a preceding WithSerializer.
+func WithCompressor(c serializer.Compressor, minSize int) Option {
+	return func(s *Service) {
+		s.serializer = serializer.Compressed(s.codec(), c, minSize)
+	}
+}
+
+// ErrNoExpiration is returned by writes without an expiration when
+// RequireExpiration is set
+var ErrNoExpiration = errors.New("goredis: an expiration is required")
+
+// RequireExpiration rejects writes passing DEFAULT when the store has no default
+// expiration, which would keep the value forever, with ErrNoExpiration. Values
+// meant to never expire must then be written with redisstore.FOREVER.
+func RequireExpiration() Option {
+	return func(s *Service) {
+		s.requireExpiration = true
+	}
+}
+
+// ErrValueTooLarge is returned by writes of a value larger than MaxValueSize
+var ErrValueTooLarge = errors.New("goredis: value too large")
+
+// MaxValueSize rejects Set, SetWith, Add and Replace of values whose serialized,
+// and compressed when WithCompressor is set, form exceeds bytes with
+// ErrValueTooLarge, before anything is sent to Redis
+func MaxValueSize(bytes int) Option {
+	return func(s *Service) {
+		s.maxValueSize = bytes
+	}
+}
+
+// ErrTypeMismatch is matched by the DecodeError of a read whose stored value
+// does not decode into the value passed
+var ErrTypeMismatch = errors.New("goredis: stored value does not match the requested type")
+
+// DecodeError is a stored value that could not be decoded into Type. errors.Is
+// matches ErrTypeMismatch, errors.Unwrap returns the serializer error.
+type DecodeError struct {
+	Key  string
+	Type string
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("goredis: cannot decode %q into %s, was it written as another type or with another serializer? %v",
+		e.Key, e.Type, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrTypeMismatch
+}
+
+// MissOnDecodeError makes Get, GetWith, GetAndTouch and the GetOrSet variants
+// treat a stored value that does not decode as absent: Get returns
+// redisstore.ErrCacheMiss and GetOrSet overwrites it with a freshly loaded value.
+func MissOnDecodeError() Option {
+	return func(s *Service) {
+		s.missOnDecodeError = true
+	}
+}
+
+// AllowFlush enables Flush and FlushAll, which otherwise fail with
+// redisstore.ErrNotSupport so a stray call cannot wipe the data of other
+// applications sharing the database. FlushPrefix is always allowed.
+func AllowFlush() Option {
+	return func(s *Service) {
+		s.allowFlush = true
+	}
+}
+
+// WithVersioning makes Set, SetWith, Add, Replace, SetIfChanged, CompareAndSwap
+// and the GetOrSet variants stamp every value they write with a version, taken
+// atomically from an INCR of the counter at VersionKey, and reads strip it.
+// GetWithVersion returns the version, so comparing versions across reads tells
+// whether the value was updated between them. Values written otherwise, e.g. by
+// Heartbeat or AddMulti, or before, read as version 0. A counter expires with
+// its value and is removed by the Delete variants, GetDel and DeleteByPrefix,
+// so versions only increase while the value is stored. Requires a RedisStore.
+func WithVersioning() Option {
+	return func(s *Service) {
+		s.versioned = true
+	}
+}
+
+// CounterConfig sets how every counter operation, Increment, Decrement,
+// IncrementMod, IncrementMulti and ZIncrBy, treats a missing counter
+type CounterConfig struct {
+	// AutoCreate creates missing counters at 0 when set. When unset, they fail
+	// with redisstore.ErrCacheMiss, or are left out of the IncrementMulti result.
+	AutoCreate bool
+}
+
+// WithCounters makes all counter operations follow cfg. Without it each keeps
+// its own default: Increment and Decrement fail on missing counters, while
+// IncrementMod, IncrementMulti and ZIncrBy create them.
+func WithCounters(cfg CounterConfig) Option {
+	return func(s *Service) {
+		s.counters = &cfg
+	}
+}
+
+// WithServerClock makes ExpireAt correct timestamps computed from the local clock,
+// e.g. time.Now().Add(ttl), for its skew from the server clock. It costs an extra
+// TIME round-trip per call.
+func WithServerClock() Option {
+	return func(s *Service) {
+		s.serverClock = true
+	}
+}
+
+// WithReplicas serves Get, GetWith, Exists and TTL from pools connected to
+// replicas, in turn, and everything else from the primary pool. Replication is
+// asynchronous, so a read may miss a recent write or see a stale value: use
+// Primary or WithPrimaryRead for reads that must observe the Service's own writes.
+func WithReplicas(pools ...*redis.Pool) Option {
+	return func(s *Service) {
+		s.replicas = &replicaSet{pools: pools}
+	}
+}
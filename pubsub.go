@@ -0,0 +1,80 @@
+package goredis
+
+import (
+	"context"
+	"strings"
+)
+
+// Publish sends message, serialized like values, to the prefixed channel
+func (s *Service) Publish(channel string, message interface{}) (int, error) {
+	message, err := s.encode(message)
+	if err != nil {
+		return 0, err
+	}
+	return s.store.Publish(s.channel(channel), message)
+}
+
+// Subscribe calls fn with the raw payload of every message published to the
+// prefixed channels. It blocks until ctx is done, reconnecting and subscribing
+// again when the connection drops; messages published in the meantime are lost.
+// WithReconnectHandler is notified of every reconnection.
+func (s *Service) Subscribe(ctx context.Context, channels []string, fn func(channel string, data []byte),
+	opts ...CallOption) error {
+	o := s.callOptions(opts)
+	prefixed := make([]string, len(channels))
+	for i, channel := range channels {
+		prefixed[i] = s.channel(channel)
+	}
+	return s.store.Subscribe(ctx, prefixed, func(channel string, data []byte) {
+		fn(strings.TrimPrefix(channel, s.prefix+":"), data)
+	}, o.onReconnect)
+}
+
+// channel prefixes the name of a channel. Channels are not keys, so neither
+// WithKeyHasher nor WithHashTag applies to them.
+func (s *Service) channel(name string) string {
+	return s.prefix + ":" + name
+}
+
+// InvalidationBus broadcasts changed keys so instances can drop local copies
+type InvalidationBus struct {
+	s       *Service
+	channel string
+}
+
+// InvalidationBus returns the bus publishing on channel. Pass the channel to
+// WithInvalidationBus to publish every key written or deleted automatically.
+func (s *Service) InvalidationBus(channel string) *InvalidationBus {
+	return &InvalidationBus{s: s, channel: channel}
+}
+
+// Publish announces that keys changed
+func (b *InvalidationBus) Publish(keys ...string) error {
+	for _, key := range keys {
+		if _, err := b.s.Publish(b.channel, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe calls evict with every key announced on the bus, this instance's own
+// writes included, until ctx is done. Announcements made while not subscribed are lost.
+func (b *InvalidationBus) Subscribe(ctx context.Context, evict func(key string)) error {
+	return b.s.Subscribe(ctx, []string{b.channel}, func(channel string, data []byte) {
+		var key string
+		if b.s.codec().Deserialize(data, &key) == nil {
+			evict(key)
+		}
+	})
+}
+
+// written announces keys on the configured invalidation bus when the write
+// succeeded, and returns its error. Publishing is best-effort: the write already
+// happened, so a failed publish is not reported.
+func (s *Service) written(err error, keys ...string) error {
+	if err == nil && s.invalidation != "" {
+		s.InvalidationBus(s.invalidation).Publish(keys...)
+	}
+	return err
+}
@@ -0,0 +1,120 @@
+package goredis
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestService_InvalidationBus(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix, WithInvalidationBus("invalidations"))
+	ctx, cancel := context.WithCancel(context.Background())
+	evicted := make(chan string, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.InvalidationBus("invalidations").Subscribe(ctx, func(key string) {
+			evicted <- key
+		})
+	}()
+	time.Sleep(100 * time.Millisecond)
+	if err := s.Set("bus", "hello", time.Minute); err != nil {
+		t.FailNow()
+	}
+	defer s.Delete("bus")
+	select {
+	case key := <-evicted:
+		if key != "bus" {
+			t.FailNow()
+		}
+	case <-time.After(time.Second):
+		t.FailNow()
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.FailNow()
+	}
+}
+
+func TestService_Subscribe_Reconnect(t *testing.T) {
+	p := CreatePool(testHost, 1, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	reconnects := 0
+	err := s.Subscribe(ctx, []string{"news"}, func(channel string, data []byte) {},
+		WithReconnectHandler(func(err error) {
+			reconnects++
+		}))
+	if err != context.DeadlineExceeded || reconnects < 2 {
+		t.FailNow()
+	}
+}
+
+func TestSubscribeTyped(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	type order struct {
+		ID    int
+		Total float64
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	orders := make(chan order, 1)
+	failures := make(chan error, 1)
+	go SubscribeTyped(ctx, s, []string{"orders"}, func(channel string, msg order) {
+		orders <- msg
+	}, WithDecodeErrorHandler(func(channel string, err error) {
+		failures <- err
+	}))
+	time.Sleep(100 * time.Millisecond)
+	if _, err := s.Publish("orders", []byte("garbage")); err != nil {
+		t.FailNow()
+	}
+	if _, err := PublishTyped(s, "orders", order{ID: 1, Total: 9.5}); err != nil {
+		t.FailNow()
+	}
+	select {
+	case <-failures:
+	case <-time.After(time.Second):
+		t.FailNow()
+	}
+	select {
+	case msg := <-orders:
+		if msg.ID != 1 || msg.Total != 9.5 {
+			t.FailNow()
+		}
+	case <-time.After(time.Second):
+		t.FailNow()
+	}
+}
+
+func TestService_Publish_Channel(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix, WithKeyHasher(strings.ToUpper),
+		WithHashTag(func(key string) string { return "tenant" }))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	received := make(chan string, 1)
+	// channels are only prefixed, neither hashed nor tagged like keys
+	go s.store.Subscribe(ctx, []string{testPrefix + ":news"}, func(channel string, data []byte) {
+		received <- string(data)
+	}, nil)
+	time.Sleep(100 * time.Millisecond)
+	if _, err := s.Publish("news", "hello"); err != nil {
+		t.FailNow()
+	}
+	select {
+	case msg := <-received:
+		if msg != "hello" {
+			t.FailNow()
+		}
+	case <-time.After(time.Second):
+		t.FailNow()
+	}
+}
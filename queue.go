@@ -0,0 +1,49 @@
+package goredis
+
+import (
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+// Queue is a FIFO queue of T backed by a Redis list: items are pushed on the
+// left and popped from the right
+type Queue[T any] struct {
+	s   *Service
+	key string
+}
+
+// NewQueue returns the queue stored in the list at key
+func NewQueue[T any](s *Service, key string) *Queue[T] {
+	return &Queue[T]{s: s, key: key}
+}
+
+// Enqueue appends item to the queue
+func (q *Queue[T]) Enqueue(item T) error {
+	_, err := q.s.LPush(q.key, item)
+	return err
+}
+
+// Dequeue removes the oldest item, ok is false when the queue is empty
+func (q *Queue[T]) Dequeue() (item T, ok bool, err error) {
+	err = q.s.RPop(q.key, &item)
+	return item, err == nil, ignoreMiss(err)
+}
+
+// DequeueWait is Dequeue waiting up to timeout for an item, zero waits indefinitely
+func (q *Queue[T]) DequeueWait(timeout time.Duration) (item T, ok bool, err error) {
+	err = q.s.BRPop(q.key, timeout, &item)
+	return item, err == nil, ignoreMiss(err)
+}
+
+// Len returns the number of queued items
+func (q *Queue[T]) Len() (int, error) {
+	return q.s.LLen(q.key)
+}
+
+func ignoreMiss(err error) error {
+	if err == redisstore.ErrCacheMiss {
+		return nil
+	}
+	return err
+}
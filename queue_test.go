@@ -0,0 +1,32 @@
+package goredis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.Delete("jobs_queue")
+	type job struct{ ID int }
+	q := NewQueue[job](s, "jobs_queue")
+	for i := 1; i <= 2; i++ {
+		if err := q.Enqueue(job{ID: i}); err != nil {
+			t.FailNow()
+		}
+	}
+	if j, ok, err := q.Dequeue(); err != nil || !ok || j.ID != 1 {
+		t.FailNow()
+	}
+	if j, ok, err := q.DequeueWait(time.Second); err != nil || !ok || j.ID != 2 {
+		t.FailNow()
+	}
+	if _, ok, err := q.Dequeue(); err != nil || ok {
+		t.FailNow()
+	}
+	if _, ok, err := q.DequeueWait(time.Second); err != nil || ok {
+		t.FailNow()
+	}
+}
@@ -1,35 +1,264 @@
 package goredis
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gomodule/redigo/redis"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
-func CreatePool(host string, port, db int, password string) *redis.Pool {
+// ErrRESP3 is returned when dialing with WithHello(3): the redigo client only parses RESP2 replies
+var ErrRESP3 = errors.New("goredis: RESP3 is not supported by the redis client")
+
+// PoolOption configures the pools returned by CreatePool
+type PoolOption func(*poolConfig)
+
+type poolConfig struct {
+	protover  int
+	username  string
+	idleCheck time.Duration
+	lifetime  time.Duration
+	name      string
+	maxActive int
+	wait      bool
+	backoff   *dialBackoff
+	noSelect  bool
+}
+
+// WithHello negotiates protover with HELLO when dialing, authenticating in the
+// same command (Redis 6+). Only protocol 2 can be used until the client speaks
+// RESP3: dialing fails with ErrRESP3 for 3, and with an error for any other
+// version but 2. Protocol reports the version a connection speaks.
+func WithHello(protover int) PoolOption {
+	return func(c *poolConfig) {
+		c.protover = protover
+	}
+}
+
+// Protocol returns the protocol version c speaks, asking the server with a
+// HELLO that changes nothing (Redis 6+)
+func Protocol(c redis.Conn) (int, error) {
+	return helloProto(c.Do("HELLO"))
+}
+
+// helloProto returns the protocol version of a HELLO reply, which alternates
+// field names and values, e.g. "proto", 2
+func helloProto(reply interface{}, err error) (int, error) {
+	fields, err := redis.Values(reply, err)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i+1 < len(fields); i += 2 {
+		if field, _ := redis.String(fields[i], nil); field == "proto" {
+			return redis.Int(fields[i+1], nil)
+		}
+	}
+	return 0, errors.New("goredis: HELLO reply without a protocol version")
+}
+
+// WithIdleCheck only PINGs borrowed connections that sat idle in the pool for
+// longer than threshold, sparing recently used ones a round-trip
+func WithIdleCheck(threshold time.Duration) PoolOption {
+	return func(c *poolConfig) {
+		c.idleCheck = threshold
+	}
+}
+
+// WithMaxConnLifetime closes connections older than lifetime instead of returning
+// them to the pool, so clients behind a load balancer periodically reconnect and
+// spread over new backends. Zero, the default, keeps connections indefinitely.
+func WithMaxConnLifetime(lifetime time.Duration) PoolOption {
+	return func(c *poolConfig) {
+		c.lifetime = lifetime
+	}
+}
+
+// WithMaxActive caps the pool to n open connections. Borrowing beyond the cap
+// fails at once with redisstore.ErrPoolExhausted, unless wait is set: the borrow
+// then blocks until a connection is returned, bounded by the Service
+// WithBorrowTimeout option or the context of the call.
+func WithMaxActive(n int, wait bool) PoolOption {
+	return func(c *poolConfig) {
+		c.maxActive = n
+		c.wait = wait
+	}
+}
+
+// WithDialBackoff delays dialing after failed dials, by base doubled on every
+// consecutive failure up to max, scaled by a random factor between 0.5 and 1.5.
+// When Redis comes back, callers then reconnect staggered instead of all at once.
+// The delay blocks the borrowing caller and is cleared by the first successful dial.
+func WithDialBackoff(base, max time.Duration) PoolOption {
+	return func(c *poolConfig) {
+		c.backoff = &dialBackoff{base: base, max: max, rnd: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	}
+}
+
+// dialBackoff tracks the consecutive dial failures of a pool
+type dialBackoff struct {
+	base, max time.Duration
+	mu        sync.Mutex
+	failures  int
+	rnd       *rand.Rand
+}
+
+// wait sleeps for the backoff due after the current failures
+func (b *dialBackoff) wait() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	if b.failures == 0 {
+		b.mu.Unlock()
+		return
+	}
+	d := b.base
+	for i := 1; i < b.failures && d < b.max; i++ {
+		d *= 2
+	}
+	if d > b.max {
+		d = b.max
+	}
+	d = time.Duration(float64(d) * (0.5 + b.rnd.Float64()))
+	b.mu.Unlock()
+	time.Sleep(d)
+}
+
+// record counts a failed dial, or clears the failures after a successful one
+func (b *dialBackoff) record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		b.failures++
+	} else {
+		b.failures = 0
+	}
+}
+
+// WithoutSelect never sends SELECT on dial, for Redis Cluster which rejects it.
+// Connections then stay on database 0 whatever db CreatePool is given; SELECT
+// is already skipped for db 0.
+func WithoutSelect() PoolOption {
+	return func(c *poolConfig) {
+		c.noSelect = true
+	}
+}
+
+// WithClientName labels every connection with CLIENT SETNAME name on dial, so
+// CLIENT LIST tells which application owns it. Spaces and control characters,
+// rejected by Redis, are replaced with "-".
+func WithClientName(name string) PoolOption {
+	return func(c *poolConfig) {
+		c.name = strings.Map(func(r rune) rune {
+			if r <= ' ' {
+				return '-'
+			}
+			return r
+		}, name)
+	}
+}
+
+// ClientName returns app suffixed with the hostname and process id, e.g.
+// "billing@web-3:4242", for WithClientName to attribute connections to a process
+func ClientName(app string) string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s@%s:%d", app, host, os.Getpid())
+}
+
+func CreatePool(host string, port, db int, password string, opts ...PoolOption) *redis.Pool {
+	return CreatePoolWithACL(host, port, db, "", password, opts...)
+}
+
+// CreatePoolWithACL is CreatePool authenticating as an ACL user (Redis 6+) with
+// AUTH username password. An empty username falls back to AUTH password.
+func CreatePoolWithACL(host string, port, db int, username, password string, opts ...PoolOption) *redis.Pool {
+	cfg := &poolConfig{username: username}
+	for _, opt := range opts {
+		opt(cfg)
+	}
 	return &redis.Pool{
-		MaxIdle:     10,
-		IdleTimeout: 180 * time.Second,
+		MaxIdle:         10,
+		IdleTimeout:     180 * time.Second,
+		MaxConnLifetime: cfg.lifetime,
+		MaxActive:       cfg.maxActive,
+		Wait:            cfg.wait,
 		Dial: func() (redis.Conn, error) {
+			cfg.backoff.wait()
 			c, err := redis.Dial("tcp", fmt.Sprintf("%s:%d", host, port))
+			cfg.backoff.record(err)
 			if err != nil {
 				return nil, err
 			}
-			if len(password) > 0 {
-				if _, err := c.Do("AUTH", password); err != nil {
+			if err := cfg.auth(c, password); err != nil {
+				c.Close()
+				return nil, err
+			}
+			if db != 0 && !cfg.noSelect {
+				if _, err := c.Do("SELECT", db); err != nil {
 					c.Close()
 					return nil, err
 				}
 			}
-			if _, err := c.Do("SELECT", db); err != nil {
-				c.Close()
-				return nil, err
+			if len(cfg.name) > 0 {
+				if _, err := c.Do("CLIENT", "SETNAME", cfg.name); err != nil {
+					c.Close()
+					return nil, err
+				}
 			}
 			return c, nil
 		},
 		TestOnBorrow: func(c redis.Conn, t time.Time) error {
+			if time.Since(t) < cfg.idleCheck {
+				return nil
+			}
 			_, err := c.Do("PING")
 			return err
 		},
 	}
 }
+
+// auth authenticates a new connection, with HELLO when a protocol is requested
+func (cfg *poolConfig) auth(c redis.Conn, password string) error {
+	if cfg.protover > 0 {
+		switch cfg.protover {
+		case 2:
+		case 3:
+			return ErrRESP3
+		default:
+			return fmt.Errorf("goredis: unknown protocol version %d", cfg.protover)
+		}
+		args := redis.Args{cfg.protover}
+		if len(password) > 0 {
+			username := cfg.username
+			if len(username) == 0 {
+				username = "default"
+			}
+			args = args.Add("AUTH", username, password)
+		}
+		proto, err := helloProto(c.Do("HELLO", args...))
+		if err == nil && proto != cfg.protover {
+			err = fmt.Errorf("goredis: server negotiated protocol %d instead of %d", proto, cfg.protover)
+		}
+		return err
+	}
+	if len(password) == 0 {
+		return nil
+	}
+	var err error
+	if len(cfg.username) > 0 {
+		_, err = c.Do("AUTH", cfg.username, password)
+	} else {
+		_, err = c.Do("AUTH", password)
+	}
+	return err
+}
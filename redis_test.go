@@ -1,6 +1,18 @@
 package goredis
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/owngoals/go-redis/redisstore"
+)
 
 const (
 	testHost     = "127.0.0.1"
@@ -16,3 +28,315 @@ func TestCreatePool(t *testing.T) {
 	}
 	p.Close()
 }
+
+func TestWithClientName(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword, WithClientName("billing worker"))
+	defer p.Close()
+	conn := p.Get()
+	defer conn.Close()
+	if name, err := redis.String(conn.Do("CLIENT", "GETNAME")); err != nil || name != "billing-worker" {
+		t.FailNow()
+	}
+	if !strings.HasPrefix(ClientName("billing"), "billing@") {
+		t.FailNow()
+	}
+}
+
+func TestWithBorrowTimeout(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword, WithMaxActive(1, true))
+	defer p.Close()
+	if p.MaxActive != 1 || !p.Wait {
+		t.FailNow()
+	}
+	p.Dial = dialUnresponsive
+	held := p.Get()
+	defer held.Close()
+	s := NewService(p, testPrefix, WithBorrowTimeout(50*time.Millisecond))
+	start := time.Now()
+	if err := s.Set("exhausted", 1, time.Minute); !errors.Is(err, redisstore.ErrPoolExhausted) {
+		t.FailNow()
+	}
+	if time.Since(start) > time.Second {
+		t.FailNow()
+	}
+}
+
+// dialUnresponsive dials a server that never replies
+func dialUnresponsive() (redis.Conn, error) {
+	client, server := net.Pipe()
+	go io.Copy(io.Discard, server)
+	return redis.NewConn(client, 0, 0), nil
+}
+
+func TestWithInterceptor(t *testing.T) {
+	p := &redis.Pool{Dial: dialUnresponsive}
+	defer p.Close()
+	slow := NewService(p, testPrefix, WithInterceptor(func(cmd string, args []interface{},
+		do func() (interface{}, error)) (interface{}, error) {
+		time.Sleep(100 * time.Millisecond)
+		return do()
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var v int
+	if err := slow.WithContext(ctx).Get("slow", &v); err != context.DeadlineExceeded {
+		t.FailNow()
+	}
+
+	attempts := 0
+	flaky := NewService(p, testPrefix, WithRetry(RetryPolicy{Attempts: 3}),
+		WithInterceptor(func(cmd string, args []interface{}, do func() (interface{}, error)) (interface{}, error) {
+			if attempts++; attempts < 3 {
+				return nil, io.EOF
+			}
+			return []byte("7"), nil
+		}))
+	if err := flaky.Get("flaky", &v); err != nil || v != 7 || attempts != 3 {
+		t.FailNow()
+	}
+}
+
+func TestWithCommandAudit(t *testing.T) {
+	p := &redis.Pool{Dial: dialUnresponsive}
+	defer p.Close()
+	var audited [][]interface{}
+	s := NewService(p, testPrefix,
+		WithCommandAudit(func(cmd string, args []interface{}) {
+			audited = append(audited, append([]interface{}{cmd}, args...))
+		}, RedactValues),
+		WithInterceptor(func(cmd string, args []interface{}, do func() (interface{}, error)) (interface{}, error) {
+			return "OK", nil
+		}))
+	if err := s.Set("password", "hunter2", time.Minute); err != nil {
+		t.FailNow()
+	}
+	if len(audited) != 1 || audited[0][0] != "SETEX" || audited[0][1] != s.Key("password") ||
+		audited[0][2] != "[redacted]" || audited[0][3] != "[redacted]" {
+		t.FailNow()
+	}
+}
+
+func TestWithDialBackoff(t *testing.T) {
+	p := CreatePool(testHost, 1, testDb, testPassword, WithDialBackoff(40*time.Millisecond, time.Second))
+	defer p.Close()
+	start := time.Now()
+	if _, err := p.Get().Do("PING"); err == nil {
+		t.FailNow()
+	}
+	first := time.Since(start)
+	start = time.Now()
+	if _, err := p.Get().Do("PING"); err == nil {
+		t.FailNow()
+	}
+	if time.Since(start) < first+20*time.Millisecond {
+		t.FailNow()
+	}
+}
+
+func TestService_Warmup(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	if n, err := s.Warmup(5); err != nil || n != 5 {
+		t.FailNow()
+	}
+	if p.IdleCount() != 5 {
+		t.FailNow()
+	}
+}
+
+func TestService_WithDB(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix, WithPoolDB(testDb))
+	other := s.WithDB(testDb + 1)
+	defer other.Delete("db")
+	if err := other.Set("db", "other", 1*time.Minute); err != nil {
+		t.FailNow()
+	}
+	if s.Exists("db") || !other.Exists("db") {
+		t.FailNow()
+	}
+}
+
+func TestService_WithDB_CancelledContext(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewService(p, testPrefix, WithPoolDB(testDb),
+		WithInterceptor(func(cmd string, args []interface{}, do func() (interface{}, error)) (interface{}, error) {
+			if cmd == "GET" {
+				cancel()
+			}
+			return do()
+		}))
+	var v string
+	if err := s.WithDB(testDb+1).WithContext(ctx).Get("db", &v); err != context.Canceled {
+		t.FailNow()
+	}
+	conn := p.Get()
+	defer conn.Close()
+	info, err := redis.String(conn.Do("CLIENT", "INFO"))
+	if err != nil || !strings.Contains(info, fmt.Sprintf(" db=%d ", testDb)) {
+		t.FailNow()
+	}
+}
+
+func TestService_WithCircuitBreaker(t *testing.T) {
+	p := CreatePool(testHost, 1, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix, WithCircuitBreaker(2, time.Minute))
+	for i := 0; i < 2; i++ {
+		if err := s.Set("breaker", 1, time.Minute); err == nil || err == redisstore.ErrCircuitOpen {
+			t.FailNow()
+		}
+	}
+	if err := s.Set("breaker", 1, time.Minute); err != redisstore.ErrCircuitOpen {
+		t.FailNow()
+	}
+}
+
+func TestCreatePool_WithHello(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword, WithHello(2))
+	defer p.Close()
+	conn := p.Get()
+	defer conn.Close()
+	if proto, err := Protocol(conn); err != nil || proto != 2 {
+		t.FailNow()
+	}
+}
+
+func TestWithHello_Protocol(t *testing.T) {
+	for protover, want := range map[int]error{3: ErrRESP3, 4: nil} {
+		cfg := &poolConfig{}
+		WithHello(protover)(cfg)
+		client, _ := net.Pipe()
+		err := cfg.auth(redis.NewConn(client, time.Second, time.Second), "")
+		if err == nil || (want != nil && err != want) {
+			t.FailNow()
+		}
+	}
+
+	hello := func() redis.Conn {
+		client, server := net.Pipe()
+		go func() {
+			buf := make([]byte, 512)
+			server.Read(buf)
+			io.WriteString(server, "*6\r\n$6\r\nserver\r\n$5\r\nredis\r\n$5\r\nproto\r\n:2\r\n$7\r\nmodules\r\n*0\r\n")
+		}()
+		return redis.NewConn(client, time.Second, time.Second)
+	}
+	cfg := &poolConfig{}
+	WithHello(2)(cfg)
+	if err := cfg.auth(hello(), ""); err != nil {
+		t.FailNow()
+	}
+	if proto, err := Protocol(hello()); err != nil || proto != 2 {
+		t.FailNow()
+	}
+}
+
+func TestCreatePool_WithoutSelect(t *testing.T) {
+	p := CreatePool(testHost, testPort, 5, testPassword, WithoutSelect())
+	defer p.Close()
+	conn := p.Get()
+	defer conn.Close()
+	if info, err := redis.String(conn.Do("CLIENT", "INFO")); err != nil || !strings.Contains(info, " db=0 ") {
+		t.FailNow()
+	}
+}
+
+func TestCreatePool_WithMaxConnLifetime(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword, WithMaxConnLifetime(time.Minute))
+	defer p.Close()
+	if p.MaxConnLifetime != time.Minute {
+		t.FailNow()
+	}
+}
+
+func TestService_Drain(t *testing.T) {
+	p := CreatePool(testHost, 1, testDb, testPassword)
+	s := NewService(p, testPrefix)
+	if err := s.Drain(context.Background()); err != nil {
+		t.FailNow()
+	}
+	if err := s.Set("drained", 1, time.Minute); err != redisstore.ErrClosed {
+		t.FailNow()
+	}
+}
+
+func TestService_NetworkError(t *testing.T) {
+	p := CreatePool(testHost, 1, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	err := s.Set("unreachable", 1, time.Minute)
+	if !errors.Is(err, redisstore.ErrNetwork) || !redisstore.Retryable(err) {
+		t.FailNow()
+	}
+}
+
+func TestService_WithReplicas(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	down := CreatePool(testHost, 1, testDb, testPassword)
+	defer down.Close()
+	s := NewService(p, testPrefix, WithReplicas(down))
+	defer s.Delete("replicated")
+	if err := s.Set("replicated", "v", time.Minute); err != nil {
+		t.FailNow()
+	}
+	var v string
+	if err := s.Get("replicated", &v); !errors.Is(err, redisstore.ErrNetwork) {
+		t.FailNow()
+	}
+	if err := s.Primary().Get("replicated", &v); err != nil || v != "v" {
+		t.FailNow()
+	}
+	if err := s.GetWith("replicated", &v, WithPrimaryRead()); err != nil || v != "v" {
+		t.FailNow()
+	}
+}
+
+func TestService_WithCircuitBreaker_Probe(t *testing.T) {
+	fail := true
+	p := &redis.Pool{MaxActive: 1, Wait: true, Dial: func() (redis.Conn, error) {
+		if fail {
+			return nil, errors.New("connection refused")
+		}
+		return dialUnresponsive()
+	}}
+	defer p.Close()
+	s := NewService(p, testPrefix, WithCircuitBreaker(1, time.Millisecond),
+		WithBorrowTimeout(20*time.Millisecond))
+	if err := s.Set("probe", 1, time.Minute); err == nil || err == redisstore.ErrCircuitOpen {
+		t.FailNow()
+	}
+	if err := s.Set("probe", 1, time.Minute); err != redisstore.ErrCircuitOpen {
+		t.FailNow()
+	}
+
+	// a probe that cannot borrow a connection lets the next call probe
+	fail = false
+	held := p.Get()
+	time.Sleep(5 * time.Millisecond)
+	if err := s.Set("probe", 1, time.Minute); !errors.Is(err, redisstore.ErrPoolExhausted) {
+		t.FailNow()
+	}
+	held.Close()
+	fail = true
+	if err := s.Set("probe", 1, time.Minute); err == nil || err == redisstore.ErrCircuitOpen {
+		t.FailNow()
+	}
+
+	// so does a probe closed without sending any command
+	fail = false
+	time.Sleep(5 * time.Millisecond)
+	if _, err := s.store.SAdd(s.Key("probe"), make(chan int)); err == nil {
+		t.FailNow()
+	}
+	fail = true
+	if err := s.Set("probe", 1, time.Minute); err == nil || err == redisstore.ErrCircuitOpen {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,149 @@
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// ErrCircuitOpen is returned without contacting Redis while the circuit breaker is open
+var ErrCircuitOpen = errors.New("cache: circuit open")
+
+// CircuitBreaker short-circuits commands after consecutive connection failures.
+// Once threshold failures in a row are seen it opens for cooldown, then lets a
+// single probe through: a success closes it again, a failure reopens it.
+// Server replies such as -ERR or a missing key never count as failures.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker returns a CircuitBreaker opening after threshold consecutive failures
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// WithCircuitBreaker returns a shallow copy of the store guarded by b
+func (c *RedisStore) WithCircuitBreaker(b *CircuitBreaker) *RedisStore {
+	cp := *c
+	cp.breaker = b
+	return &cp
+}
+
+// allow reports whether a command may run, and whether it is the probe of an
+// open breaker, whose outcome must then be recorded or released
+func (b *CircuitBreaker) allow() (allowed, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true, false
+	}
+	if b.probing || time.Since(b.openedAt) < b.cooldown {
+		return false, false
+	}
+	b.probing = true
+	return true, true
+}
+
+// release ends a probe that ran no command, letting the next call probe instead
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// abort records err when no connection could be borrowed because Redis is
+// unreachable, and otherwise releases the probe if the borrow was one
+func (b *CircuitBreaker) abort(err error, probe bool) {
+	if connectionFailure(err) {
+		b.record(err)
+	} else if probe {
+		b.release()
+	}
+}
+
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !connectionFailure(err) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
+
+// connectionFailure reports whether err means Redis could not be reached, as
+// opposed to a reply from the server or an error of the caller's own making
+func connectionFailure(err error) bool {
+	if err == nil || err == redis.ErrNil || err == redis.ErrPoolExhausted ||
+		err == context.Canceled || err == context.DeadlineExceeded {
+		return false
+	}
+	_, reply := err.(redis.Error)
+	return !reply
+}
+
+// breakerConn reports the outcome of every command to the breaker. A probe
+// closed without any outcome, e.g. after a serialization error, releases it.
+type breakerConn struct {
+	redis.Conn
+	breaker  *CircuitBreaker
+	probe    bool
+	recorded bool
+}
+
+func (c *breakerConn) record(err error) {
+	c.recorded = true
+	c.breaker.record(err)
+}
+
+func (c *breakerConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	reply, err := c.Conn.Do(cmd, args...)
+	c.record(err)
+	return reply, err
+}
+
+func (c *breakerConn) DoWithTimeout(timeout time.Duration, cmd string, args ...interface{}) (interface{}, error) {
+	reply, err := redis.DoWithTimeout(c.Conn, timeout, cmd, args...)
+	c.record(err)
+	return reply, err
+}
+
+func (c *breakerConn) Flush() error {
+	err := c.Conn.Flush()
+	if err != nil {
+		c.record(err)
+	}
+	return err
+}
+
+func (c *breakerConn) Receive() (interface{}, error) {
+	reply, err := c.Conn.Receive()
+	c.record(err)
+	return reply, err
+}
+
+func (c *breakerConn) ReceiveWithTimeout(timeout time.Duration) (interface{}, error) {
+	reply, err := redis.ReceiveWithTimeout(c.Conn, timeout)
+	c.record(err)
+	return reply, err
+}
+
+func (c *breakerConn) Close() error {
+	if c.probe && !c.recorded {
+		c.breaker.release()
+	}
+	return c.Conn.Close()
+}
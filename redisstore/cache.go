@@ -8,7 +8,8 @@ type CacheStore interface {
 	// whether the key was found.
 	Get(key string, value interface{}) error
 
-	// Set sets an item to the cache, replacing any existing item.
+	// Set sets an item to the cache, replacing any existing item. Writing a nil value
+	// returns ErrNilValue, as do Add and Replace.
 	Set(key string, value interface{}, expire time.Duration) error
 
 	// Add adds an item to the cache only if an item doesn't already exist for the given
@@ -31,3 +32,16 @@ type CacheStore interface {
 	// Flush seletes all items from the cache.
 	Flush() error
 }
+
+// Store is a CacheStore that can also check for keys and update their expiration.
+// It is implemented by RedisStore and MemoryStore.
+type Store interface {
+	CacheStore
+
+	// Exists reports whether key is in the cache.
+	Exists(key string) bool
+
+	// SetExpire updates the expiration of key, a non-positive duration removes it.
+	// Returns false when the key is not in the cache.
+	SetExpire(key string, expires time.Duration) bool
+}
@@ -0,0 +1,112 @@
+package redisstore
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// capabilities caches which commands the server implements, shared by the
+// copies of a store. Commands are learned missing from the first rejection,
+// or all at once from a COMMAND probe.
+type capabilities struct {
+	mu       sync.Mutex
+	commands map[string]bool
+	missing  map[string]bool
+}
+
+func (c *capabilities) lacks(cmd string) bool {
+	if c == nil {
+		return false
+	}
+	cmd = strings.ToLower(cmd)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.missing[cmd] || (c.commands != nil && !c.commands[cmd])
+}
+
+func (c *capabilities) markMissing(cmd string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.missing == nil {
+		c.missing = make(map[string]bool)
+	}
+	c.missing[strings.ToLower(cmd)] = true
+}
+
+// SupportedCommands returns the lower-cased names of the commands the server
+// implements, modules included, from a COMMAND probe cached for the life of the store
+func (c *RedisStore) SupportedCommands() ([]string, error) {
+	commands, err := c.commands()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// SupportsCommand reports whether the server implements the top-level command
+// cmd, e.g. "GETDEL". Subcommands, like OBJECT FREQ, are not listed.
+func (c *RedisStore) SupportsCommand(cmd string) (bool, error) {
+	commands, err := c.commands()
+	if err != nil {
+		return false, err
+	}
+	return commands[strings.ToLower(cmd)], nil
+}
+
+// commands probes the server with COMMAND once
+func (c *RedisStore) commands() (map[string]bool, error) {
+	if c.caps == nil {
+		return nil, ErrNotSupport
+	}
+	c.caps.mu.Lock()
+	commands := c.caps.commands
+	c.caps.mu.Unlock()
+	if commands != nil {
+		return commands, nil
+	}
+	conn := c.conn()
+	defer conn.Close()
+	reply, err := redis.Values(conn.Do("COMMAND"))
+	if err != nil {
+		return nil, err
+	}
+	commands = make(map[string]bool, len(reply))
+	for _, entry := range reply {
+		info, err := redis.Values(entry, nil)
+		if err != nil || len(info) == 0 {
+			continue
+		}
+		if name, err := redis.String(info[0], nil); err == nil {
+			commands[strings.ToLower(name)] = true
+		}
+	}
+	c.caps.mu.Lock()
+	c.caps.commands = commands
+	c.caps.mu.Unlock()
+	return commands, nil
+}
+
+// doOptional is conn.Do for a command the server may not implement. Once the
+// server is known to lack cmd it fails with the server's unknown command error
+// without a round-trip, so fallbacks are decided once.
+func (c *RedisStore) doOptional(conn redis.Conn, cmd string, args ...interface{}) (interface{}, error) {
+	if c.caps.lacks(cmd) {
+		return nil, redis.Error("ERR unknown command '" + cmd + "'")
+	}
+	reply, err := conn.Do(cmd, args...)
+	if unknownCommand(err) {
+		c.caps.markMissing(cmd)
+	}
+	return reply, err
+}
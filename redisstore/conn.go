@@ -0,0 +1,238 @@
+package redisstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// WithContext returns a shallow copy of the store whose commands borrow
+// connections with ctx and give up once ctx is done or its deadline passes
+func (c *RedisStore) WithContext(ctx context.Context) *RedisStore {
+	cp := *c
+	cp.ctx = ctx
+	return &cp
+}
+
+// WithDB returns a shallow copy of the store whose connections SELECT db when
+// borrowed and SELECT resetDB, the database of the pool, before going back to it
+func (c *RedisStore) WithDB(db, resetDB int) *RedisStore {
+	cp := *c
+	cp.selectDB = true
+	cp.db = db
+	cp.resetDB = resetDB
+	return &cp
+}
+
+// WithPool returns a shallow copy of the store borrowing connections from pool,
+// e.g. one connected to a replica. The circuit breaker tracks the health of the
+// original pool and is not carried over.
+func (c *RedisStore) WithPool(pool *redis.Pool) *RedisStore {
+	cp := *c
+	cp.pool = pool
+	cp.breaker = nil
+	return &cp
+}
+
+// WithBorrowTimeout returns a shallow copy of the store failing commands with
+// ErrPoolExhausted when no connection could be borrowed within timeout from a
+// pool that waits for one
+func (c *RedisStore) WithBorrowTimeout(timeout time.Duration) *RedisStore {
+	cp := *c
+	cp.borrowTimeout = timeout
+	return &cp
+}
+
+// Pool returns the pool the store borrows connections from
+func (c *RedisStore) Pool() *redis.Pool {
+	return c.pool
+}
+
+// conn borrows a connection from the pool, bound to the store context if any.
+// A store without a pool fails every command with ErrNotSupport.
+func (c *RedisStore) conn() redis.Conn {
+	if c.pool == nil {
+		return errorConn{ErrNotSupport}
+	}
+	probe := false
+	if c.breaker != nil {
+		var allowed bool
+		if allowed, probe = c.breaker.allow(); !allowed {
+			return errorConn{ErrCircuitOpen}
+		}
+	}
+	if !c.drain.acquire() {
+		if probe {
+			c.breaker.release()
+		}
+		return errorConn{ErrClosed}
+	}
+	pooled, err := c.borrow()
+	if err != nil {
+		c.drain.release()
+		if c.breaker != nil {
+			c.breaker.abort(err, probe)
+		}
+		return errorConn{classify(err)}
+	}
+	conn := pooled
+	if c.ctx != nil {
+		conn = &contextConn{Conn: pooled, ctx: c.ctx}
+	}
+	if c.intercept != nil {
+		conn = &interceptConn{Conn: conn, intercept: c.intercept}
+	}
+	conn = &drainConn{Conn: conn, drain: c.drain}
+	if c.breaker != nil {
+		conn = &breakerConn{Conn: conn, breaker: c.breaker, probe: probe}
+	}
+	if c.selectDB {
+		if _, err := conn.Do("SELECT", c.db); err != nil {
+			conn.Close()
+			return errorConn{classify(err)}
+		}
+		conn = &dbConn{Conn: conn, pooled: pooled, resetDB: c.resetDB}
+	}
+	return classifyConn{conn}
+}
+
+// borrow gets a connection from the pool, with the store context if any,
+// waiting at most the borrow timeout for one
+func (c *RedisStore) borrow() (redis.Conn, error) {
+	if c.ctx == nil && c.borrowTimeout <= 0 {
+		return c.pool.Get(), nil
+	}
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	borrowCtx := ctx
+	if c.borrowTimeout > 0 {
+		var cancel context.CancelFunc
+		borrowCtx, cancel = context.WithTimeout(ctx, c.borrowTimeout)
+		defer cancel()
+	}
+	pc, err := c.pool.GetContext(borrowCtx)
+	if err == context.DeadlineExceeded && ctx.Err() == nil {
+		return nil, ErrPoolExhausted
+	}
+	return pc, err
+}
+
+// dbConn switches back to the pool database before the connection is returned.
+// The SELECT goes straight to the pooled connection, so a done context cannot
+// skip it, and a connection it fails on is discarded rather than reused.
+type dbConn struct {
+	redis.Conn
+	pooled  redis.Conn
+	resetDB int
+}
+
+func (c *dbConn) Close() error {
+	if _, err := c.pooled.Do("SELECT", c.resetDB); err != nil {
+		discard(c.pooled)
+	}
+	return c.Conn.Close()
+}
+
+// discard makes the pool close the pooled connection pc when it is returned:
+// redigo treats a failed read, here one timing out at once, as fatal to it
+func discard(pc redis.Conn) {
+	redis.ReceiveWithTimeout(pc, time.Nanosecond)
+}
+
+func (c *dbConn) DoWithTimeout(timeout time.Duration, cmd string, args ...interface{}) (interface{}, error) {
+	return redis.DoWithTimeout(c.Conn, timeout, cmd, args...)
+}
+
+func (c *dbConn) ReceiveWithTimeout(timeout time.Duration) (interface{}, error) {
+	return redis.ReceiveWithTimeout(c.Conn, timeout)
+}
+
+// contextConn bounds every read by the deadline of ctx. redigo cannot abort
+// a read in flight, so cancellation without a deadline is only observed
+// between commands.
+type contextConn struct {
+	redis.Conn
+	ctx context.Context
+}
+
+func (c *contextConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	if _, ok := c.ctx.Deadline(); !ok {
+		if err := c.ctx.Err(); err != nil {
+			return nil, err
+		}
+		return c.Conn.Do(cmd, args...)
+	}
+	return c.DoWithTimeout(0, cmd, args...)
+}
+
+func (c *contextConn) DoWithTimeout(timeout time.Duration, cmd string, args ...interface{}) (interface{}, error) {
+	timeout, err := c.timeout(timeout)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := redis.DoWithTimeout(c.Conn, timeout, cmd, args...)
+	return reply, c.wrap(err)
+}
+
+func (c *contextConn) Receive() (interface{}, error) {
+	if _, ok := c.ctx.Deadline(); !ok {
+		if err := c.ctx.Err(); err != nil {
+			return nil, err
+		}
+		return c.Conn.Receive()
+	}
+	return c.ReceiveWithTimeout(0)
+}
+
+func (c *contextConn) ReceiveWithTimeout(timeout time.Duration) (interface{}, error) {
+	timeout, err := c.timeout(timeout)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := redis.ReceiveWithTimeout(c.Conn, timeout)
+	return reply, c.wrap(err)
+}
+
+// timeout shortens timeout to the time left before the context deadline
+func (c *contextConn) timeout(timeout time.Duration) (time.Duration, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	deadline, ok := c.ctx.Deadline()
+	if !ok {
+		return timeout, nil
+	}
+	left := time.Until(deadline)
+	if left <= 0 {
+		return 0, context.DeadlineExceeded
+	}
+	if timeout == 0 || left < timeout {
+		timeout = left
+	}
+	return timeout, nil
+}
+
+// wrap reports the context error in place of the read timeout it caused
+func (c *contextConn) wrap(err error) error {
+	if err != nil && c.ctx.Err() != nil {
+		return c.ctx.Err()
+	}
+	return err
+}
+
+// errorConn is returned when no connection could be borrowed, every command fails with err
+type errorConn struct{ err error }
+
+func (ec errorConn) Close() error                                          { return nil }
+func (ec errorConn) Err() error                                            { return ec.err }
+func (ec errorConn) Do(string, ...interface{}) (interface{}, error)        { return nil, ec.err }
+func (ec errorConn) Send(string, ...interface{}) error                     { return ec.err }
+func (ec errorConn) Flush() error                                          { return ec.err }
+func (ec errorConn) Receive() (interface{}, error)                         { return nil, ec.err }
+func (ec errorConn) ReceiveWithTimeout(time.Duration) (interface{}, error) { return nil, ec.err }
+func (ec errorConn) DoWithTimeout(time.Duration, string, ...interface{}) (interface{}, error) {
+	return nil, ec.err
+}
@@ -0,0 +1,9 @@
+package redisstore
+
+// Do sends an arbitrary command on a connection of the store, honouring its
+// context, database and circuit breaker, and returns the raw reply
+func (c *RedisStore) Do(cmd string, args ...interface{}) (interface{}, error) {
+	conn := c.conn()
+	defer conn.Close()
+	return conn.Do(cmd, args...)
+}
@@ -0,0 +1,89 @@
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// ErrClosed is returned by commands issued once the store is drained
+var ErrClosed = errors.New("cache: store is closed")
+
+// drainer tracks the connections in use so Drain can wait for them. It is
+// shared by the copies of a store.
+type drainer struct {
+	mu     sync.Mutex
+	closed bool
+	active sync.WaitGroup
+}
+
+// acquire registers a connection about to be borrowed, false once draining
+func (d *drainer) acquire() bool {
+	if d == nil {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return false
+	}
+	d.active.Add(1)
+	return true
+}
+
+func (d *drainer) release() {
+	if d != nil {
+		d.active.Done()
+	}
+}
+
+// Drain makes new commands fail with ErrClosed, waits until the connections in
+// use are returned, then closes the pool. It gives up with the ctx error when
+// ctx is done first, leaving the pool open. Subscriptions hold their connection
+// until they end, cancel them before draining.
+func (c *RedisStore) Drain(ctx context.Context) error {
+	if c.drain == nil {
+		return nil
+	}
+	c.drain.mu.Lock()
+	c.drain.closed = true
+	c.drain.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		c.drain.active.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if c.pool == nil {
+		return nil
+	}
+	return c.pool.Close()
+}
+
+// drainConn releases its slot in the drainer once closed
+type drainConn struct {
+	redis.Conn
+	drain *drainer
+	once  sync.Once
+}
+
+func (c *drainConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.drain.release)
+	return err
+}
+
+func (c *drainConn) DoWithTimeout(timeout time.Duration, cmd string, args ...interface{}) (interface{}, error) {
+	return redis.DoWithTimeout(c.Conn, timeout, cmd, args...)
+}
+
+func (c *drainConn) ReceiveWithTimeout(timeout time.Duration) (interface{}, error) {
+	return redis.ReceiveWithTimeout(c.Conn, timeout)
+}
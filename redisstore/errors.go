@@ -0,0 +1,130 @@
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Categories of command failures. Commands return them wrapped in a
+// CommandError, match them with errors.Is.
+var (
+	// ErrNetwork is a failure to reach the server or read its reply
+	ErrNetwork = errors.New("cache: network error")
+	// ErrProtocol is a reply the client could not parse
+	ErrProtocol = errors.New("cache: protocol error")
+	// ErrWrongType is a command run against a key holding another type
+	ErrWrongType = errors.New("cache: wrong type")
+	// ErrOOM is a write rejected because the server reached maxmemory
+	ErrOOM = errors.New("cache: out of memory")
+	// ErrReadOnly is a write sent to a replica, typically right after a failover
+	ErrReadOnly = errors.New("cache: read-only replica")
+)
+
+// CommandError is a command failure classified into one of the error categories.
+// errors.Is matches its Category, errors.As and errors.Is also see the original
+// error, e.g. a redis.Error or a *net.OpError.
+type CommandError struct {
+	Category error
+	Err      error
+}
+
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+func (e *CommandError) Is(target error) bool {
+	return target == e.Category
+}
+
+// Retryable reports whether err may not happen again when retried: network
+// failures, and OOM and ReadOnly errors which clear once memory is freed or a
+// failover completes. Other server errors, like WrongType, are deterministic.
+func Retryable(err error) bool {
+	return errors.Is(err, ErrNetwork) || errors.Is(err, ErrOOM) || errors.Is(err, ErrReadOnly) ||
+		category(err) == ErrNetwork
+}
+
+// classify wraps err in a CommandError when it falls in a category. Other
+// errors, including the generic server errors inspected by the store, are
+// returned unchanged.
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*CommandError); ok {
+		return err
+	}
+	if c := category(err); c != nil {
+		return &CommandError{Category: c, Err: err}
+	}
+	return err
+}
+
+func category(err error) error {
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return nil
+	}
+	if e, ok := err.(redis.Error); ok {
+		switch {
+		case strings.HasPrefix(string(e), "WRONGTYPE"):
+			return ErrWrongType
+		case strings.HasPrefix(string(e), "OOM"):
+			return ErrOOM
+		case strings.HasPrefix(string(e), "READONLY"):
+			return ErrReadOnly
+		}
+		return nil
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrNetwork
+	}
+	// redigo reports unparsable replies with an unexported protocolError
+	if strings.Contains(err.Error(), "possible server error") {
+		return ErrProtocol
+	}
+	return nil
+}
+
+// classifyConn classifies the errors of every command
+type classifyConn struct {
+	redis.Conn
+}
+
+func (c classifyConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	reply, err := c.Conn.Do(cmd, args...)
+	return reply, classify(err)
+}
+
+func (c classifyConn) Send(cmd string, args ...interface{}) error {
+	return classify(c.Conn.Send(cmd, args...))
+}
+
+func (c classifyConn) Flush() error {
+	return classify(c.Conn.Flush())
+}
+
+func (c classifyConn) Receive() (interface{}, error) {
+	reply, err := c.Conn.Receive()
+	return reply, classify(err)
+}
+
+func (c classifyConn) DoWithTimeout(timeout time.Duration, cmd string, args ...interface{}) (interface{}, error) {
+	reply, err := redis.DoWithTimeout(c.Conn, timeout, cmd, args...)
+	return reply, classify(err)
+}
+
+func (c classifyConn) ReceiveWithTimeout(timeout time.Duration) (interface{}, error) {
+	reply, err := redis.ReceiveWithTimeout(c.Conn, timeout)
+	return reply, classify(err)
+}
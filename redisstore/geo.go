@@ -0,0 +1,24 @@
+package redisstore
+
+import "github.com/gomodule/redigo/redis"
+
+// GeoAdd adds member at the lon/lat position to the geo set stored at key
+func (c *RedisStore) GeoAdd(key string, lon, lat float64, member string) error {
+	conn := c.conn()
+	defer conn.Close()
+	_, err := conn.Do("GEOADD", key, lon, lat, member)
+	return err
+}
+
+// GeoRadius returns the members of the geo set stored at key within radius of
+// lon/lat, nearest first. unit is one of m, km, ft or mi. Servers older than
+// Redis 6.2 fall back to GEORADIUS.
+func (c *RedisStore) GeoRadius(key string, lon, lat, radius float64, unit string) ([]string, error) {
+	conn := c.conn()
+	defer conn.Close()
+	members, err := redis.Strings(c.doOptional(conn, "GEOSEARCH", key, "FROMLONLAT", lon, lat, "BYRADIUS", radius, unit, "ASC"))
+	if unknownCommand(err) {
+		members, err = redis.Strings(conn.Do("GEORADIUS", key, lon, lat, radius, unit, "ASC"))
+	}
+	return members, err
+}
@@ -0,0 +1,110 @@
+package redisstore
+
+import (
+	"reflect"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/owngoals/go-redis/serializer"
+)
+
+// HSet sets field of the hash stored at key to value
+func (c *RedisStore) HSet(key, field string, value interface{}) error {
+	b, err := serializer.Serialize(value)
+	if err != nil {
+		return err
+	}
+	conn := c.conn()
+	defer conn.Close()
+	_, err = conn.Do("HSET", key, field, b)
+	return err
+}
+
+// HGet reads field of the hash stored at key into ptrValue, ErrCacheMiss when absent
+func (c *RedisStore) HGet(key, field string, ptrValue interface{}) error {
+	conn := c.conn()
+	defer conn.Close()
+	item, err := redis.Bytes(conn.Do("HGET", key, field))
+	if err == redis.ErrNil {
+		return ErrCacheMiss
+	}
+	if err != nil {
+		return err
+	}
+	return serializer.Deserialize(item, ptrValue)
+}
+
+// HDel removes fields from the hash stored at key and returns how many existed
+func (c *RedisStore) HDel(key string, fields ...string) (int, error) {
+	if len(fields) == 0 {
+		return 0, nil
+	}
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Int(conn.Do("HDEL", redis.Args{key}.AddFlat(fields)...))
+}
+
+// HExists reports whether field is set in the hash stored at key
+func (c *RedisStore) HExists(key, field string) (bool, error) {
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Bool(conn.Do("HEXISTS", key, field))
+}
+
+// HMGet reads fields of the hash stored at key with a single HMGET. A field whose
+// entry in out is a pointer is deserialized into it, any other entry is set to the
+// raw stored []byte. Fields absent from the hash are removed from out.
+func (c *RedisStore) HMGet(key string, fields []string, out map[string]interface{}) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	conn := c.conn()
+	defer conn.Close()
+	items, err := redis.ByteSlices(conn.Do("HMGET", redis.Args{key}.AddFlat(fields)...))
+	if err != nil {
+		return err
+	}
+	for i, field := range fields {
+		if items[i] == nil {
+			delete(out, field)
+			continue
+		}
+		if ptr := out[field]; ptr != nil && reflect.ValueOf(ptr).Kind() == reflect.Ptr {
+			if err := serializer.Deserialize(items[i], ptr); err != nil {
+				return err
+			}
+			continue
+		}
+		out[field] = items[i]
+	}
+	return nil
+}
+
+// HGetAll returns every field of the hash stored at key with its raw value,
+// ErrCacheMiss when the hash does not exist
+func (c *RedisStore) HGetAll(key string) (map[string][]byte, error) {
+	conn := c.conn()
+	defer conn.Close()
+	items, err := redis.ByteSlices(conn.Do("HGETALL", key))
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrCacheMiss
+	}
+	fields := make(map[string][]byte, len(items)/2)
+	for i := 0; i+1 < len(items); i += 2 {
+		fields[string(items[i])] = items[i+1]
+	}
+	return fields, nil
+}
+
+// HSetMulti sets several fields of the hash stored at key to their raw values with a single HSET
+func (c *RedisStore) HSetMulti(key string, fields map[string][]byte) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	conn := c.conn()
+	defer conn.Close()
+	_, err := conn.Do("HSET", redis.Args{key}.AddFlat(fields)...)
+	return err
+}
@@ -0,0 +1,61 @@
+package redisstore
+
+import (
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Interceptor wraps the execution of every command sent on a connection: do
+// runs the command and returns its reply. An interceptor can delay the command,
+// e.g. to simulate a slow server, or return its own reply or error, e.g. io.EOF
+// to simulate a dropped connection, without calling do. Pipelined commands only
+// see their Send intercepted, with a nil reply.
+type Interceptor func(cmd string, args []interface{}, do func() (interface{}, error)) (interface{}, error)
+
+// WithInterceptor returns a shallow copy of the store running its commands
+// through i, inside the interceptors set before
+func (c *RedisStore) WithInterceptor(i Interceptor) *RedisStore {
+	cp := *c
+	if outer := c.intercept; outer != nil {
+		cp.intercept = func(cmd string, args []interface{}, do func() (interface{}, error)) (interface{}, error) {
+			return outer(cmd, args, func() (interface{}, error) {
+				return i(cmd, args, do)
+			})
+		}
+	} else {
+		cp.intercept = i
+	}
+	return &cp
+}
+
+// interceptConn runs commands through the store interceptor. It wraps the
+// connection bound to the store context, so a delay counts against its deadline,
+// and is wrapped by the circuit breaker, so injected failures trip it.
+type interceptConn struct {
+	redis.Conn
+	intercept Interceptor
+}
+
+func (c *interceptConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	return c.intercept(cmd, args, func() (interface{}, error) {
+		return c.Conn.Do(cmd, args...)
+	})
+}
+
+func (c *interceptConn) DoWithTimeout(timeout time.Duration, cmd string, args ...interface{}) (interface{}, error) {
+	return c.intercept(cmd, args, func() (interface{}, error) {
+		return redis.DoWithTimeout(c.Conn, timeout, cmd, args...)
+	})
+}
+
+func (c *interceptConn) Send(cmd string, args ...interface{}) error {
+	_, err := c.intercept(cmd, args, func() (interface{}, error) {
+		return nil, c.Conn.Send(cmd, args...)
+	})
+	return err
+}
+
+func (c *interceptConn) ReceiveWithTimeout(timeout time.Duration) (interface{}, error) {
+	return redis.ReceiveWithTimeout(c.Conn, timeout)
+}
@@ -0,0 +1,42 @@
+package redisstore
+
+import (
+	"encoding/json"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// JSONGet decodes the value at path of the RedisJSON document stored at key into
+// ptrValue. Returns ErrCacheMiss when the key does not exist and ErrNotSupport
+// when the RedisJSON module is not loaded.
+func (c *RedisStore) JSONGet(key, path string, ptrValue interface{}) error {
+	conn := c.conn()
+	defer conn.Close()
+	b, err := redis.Bytes(c.doOptional(conn, "JSON.GET", key, path))
+	if err == redis.ErrNil {
+		return ErrCacheMiss
+	}
+	if unknownCommand(err) {
+		return ErrNotSupport
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, ptrValue)
+}
+
+// JSONSet encodes value as JSON and stores it at path of the RedisJSON document
+// stored at key. Returns ErrNotSupport when the RedisJSON module is not loaded.
+func (c *RedisStore) JSONSet(key, path string, value interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	conn := c.conn()
+	defer conn.Close()
+	_, err = c.doOptional(conn, "JSON.SET", key, path, b)
+	if unknownCommand(err) {
+		return ErrNotSupport
+	}
+	return err
+}
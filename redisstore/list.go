@@ -0,0 +1,268 @@
+package redisstore
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/owngoals/go-redis/serializer"
+)
+
+// blockingReadMargin is added to the read timeout of blocking commands so the
+// server gives up before the client does
+const blockingReadMargin = 5 * time.Second
+
+// LPush prepends values to the list stored at key and returns its new length
+func (c *RedisStore) LPush(key string, values ...interface{}) (int, error) {
+	return c.push("LPUSH", key, values)
+}
+
+// RPush appends values to the list stored at key and returns its new length
+func (c *RedisStore) RPush(key string, values ...interface{}) (int, error) {
+	return c.push("RPUSH", key, values)
+}
+
+// LPop removes the first element of the list into ptrValue, ErrCacheMiss when the list is empty
+func (c *RedisStore) LPop(key string, ptrValue interface{}) error {
+	return c.pop("LPOP", key, ptrValue)
+}
+
+// RPop removes the last element of the list into ptrValue, ErrCacheMiss when the list is empty
+func (c *RedisStore) RPop(key string, ptrValue interface{}) error {
+	return c.pop("RPOP", key, ptrValue)
+}
+
+// BLPop is the blocking variant of LPop. It waits up to timeout for an element,
+// a zero timeout blocks indefinitely. Returns ErrCacheMiss when the timeout expires.
+func (c *RedisStore) BLPop(key string, timeout time.Duration, ptrValue interface{}) error {
+	return c.blockingPop("BLPOP", key, timeout, ptrValue)
+}
+
+// BRPop is the blocking variant of RPop, see BLPop
+func (c *RedisStore) BRPop(key string, timeout time.Duration, ptrValue interface{}) error {
+	return c.blockingPop("BRPOP", key, timeout, ptrValue)
+}
+
+// LLen returns the length of the list stored at key, 0 when it does not exist
+func (c *RedisStore) LLen(key string) (int, error) {
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Int(conn.Do("LLEN", key))
+}
+
+// LPos returns the index of the first element of the list stored at key equal
+// to the serialized value, ErrCacheMiss when there is none
+func (c *RedisStore) LPos(key string, value interface{}) (int, error) {
+	b, err := serializer.Serialize(value)
+	if err != nil {
+		return 0, err
+	}
+	conn := c.conn()
+	defer conn.Close()
+	i, err := redis.Int(conn.Do("LPOS", key, b))
+	if err == redis.ErrNil {
+		return 0, ErrCacheMiss
+	}
+	return i, err
+}
+
+// LPosN returns the indexes of up to count elements equal to the serialized
+// value, 0 for all of them, starting from the rank-th match. A negative rank
+// counts matches from the tail of the list.
+func (c *RedisStore) LPosN(key string, value interface{}, rank, count int) ([]int, error) {
+	b, err := serializer.Serialize(value)
+	if err != nil {
+		return nil, err
+	}
+	conn := c.conn()
+	defer conn.Close()
+	args := redis.Args{key, b, "COUNT", count}
+	if rank != 0 {
+		args = args.Add("RANK", rank)
+	}
+	return redis.Ints(conn.Do("LPOS", args...))
+}
+
+// LTrim trims the list stored at key to the inclusive range start..stop
+func (c *RedisStore) LTrim(key string, start, stop int) error {
+	conn := c.conn()
+	defer conn.Close()
+	_, err := conn.Do("LTRIM", key, start, stop)
+	return err
+}
+
+// PushCapped prepends value to the list and trims it to its max newest
+// elements in a single MULTI/EXEC transaction. max must be positive.
+func (c *RedisStore) PushCapped(key string, value interface{}, max int) error {
+	if max <= 0 {
+		return errors.New("cache: non-positive list cap")
+	}
+	b, err := serializer.Serialize(value)
+	if err != nil {
+		return err
+	}
+	conn := c.conn()
+	defer conn.Close()
+	if err := conn.Send("MULTI"); err != nil {
+		return err
+	}
+	if err := conn.Send("LPUSH", key, b); err != nil {
+		return err
+	}
+	if err := conn.Send("LTRIM", key, 0, max-1); err != nil {
+		return err
+	}
+	reply, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return err
+	}
+	// commands failing inside the transaction, e.g. on a key of another type,
+	// only report it in their element of the EXEC reply
+	for _, r := range reply {
+		if err, ok := r.(redis.Error); ok {
+			return classify(err)
+		}
+	}
+	return nil
+}
+
+// reclaimBatch is the number of members ReclaimExpired moves per script run
+const reclaimBatch = 100
+
+var reclaimScript = redis.NewScript(2, `
+local members = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+for _, member in ipairs(members) do
+	redis.call("ZREM", KEYS[1], member)
+	redis.call("RPUSH", KEYS[2], member)
+end
+return #members`)
+
+// ReclaimExpired moves the members of the sorted set inflightKey scored at or
+// before now, in Unix milliseconds, to the tail of the list pendingKey, and
+// returns how many it moved. Members move in batches, each one atomically, so a
+// large backlog does not block the server in a single script.
+func (c *RedisStore) ReclaimExpired(inflightKey, pendingKey string, now int64) (int, error) {
+	conn := c.conn()
+	defer conn.Close()
+	moved := 0
+	for {
+		n, err := redis.Int(reclaimScript.Do(conn, inflightKey, pendingKey, now, reclaimBatch))
+		moved += n
+		if err != nil || n < reclaimBatch {
+			return moved, err
+		}
+	}
+}
+
+func (c *RedisStore) push(cmd, key string, values []interface{}) (int, error) {
+	conn := c.conn()
+	defer conn.Close()
+	args := redis.Args{key}
+	for _, value := range values {
+		b, err := serializer.Serialize(value)
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, b)
+	}
+	return redis.Int(conn.Do(cmd, args...))
+}
+
+func (c *RedisStore) pop(cmd, key string, ptrValue interface{}) error {
+	conn := c.conn()
+	defer conn.Close()
+	item, err := redis.Bytes(conn.Do(cmd, key))
+	if err == redis.ErrNil {
+		return ErrCacheMiss
+	}
+	if err != nil {
+		return err
+	}
+	return serializer.Deserialize(item, ptrValue)
+}
+
+func (c *RedisStore) blockingPop(cmd, key string, timeout time.Duration, ptrValue interface{}) error {
+	conn := c.conn()
+	defer conn.Close()
+	reply, err := redis.ByteSlices(redis.DoWithTimeout(conn, blockingReadTimeout(timeout),
+		cmd, key, blockingSeconds(timeout)))
+	if err == redis.ErrNil {
+		return ErrCacheMiss
+	}
+	if err != nil {
+		return err
+	}
+	return serializer.Deserialize(reply[1], ptrValue)
+}
+
+// BLMPop waits up to timeout, zero blocks indefinitely, for an element to pop
+// from the first non-empty list of keys, from its head when fromLeft, and
+// returns the key of that list (Redis 7+). Returns ErrCacheMiss when the
+// timeout expires with all lists still empty.
+func (c *RedisStore) BLMPop(timeout time.Duration, keys []string, fromLeft bool, ptrValue interface{}) (string, error) {
+	conn := c.conn()
+	defer conn.Close()
+	args := redis.Args{blockingSeconds(timeout), len(keys)}.AddFlat(keys).Add(listEnd(fromLeft))
+	key, items, err := mpopReply(redis.DoWithTimeout(conn, blockingReadTimeout(timeout), "BLMPOP", args...))
+	if err != nil {
+		return "", err
+	}
+	return key, serializer.Deserialize(items[0], ptrValue)
+}
+
+// LMPop pops up to count elements from the first non-empty list of keys, from
+// its head when fromLeft, and returns the key of that list with the elements
+// in stored form (Redis 7+). Returns ErrCacheMiss when all lists are empty.
+func (c *RedisStore) LMPop(keys []string, count int, fromLeft bool) (string, [][]byte, error) {
+	conn := c.conn()
+	defer conn.Close()
+	args := redis.Args{len(keys)}.AddFlat(keys).Add(listEnd(fromLeft), "COUNT", count)
+	return mpopReply(conn.Do("LMPOP", args...))
+}
+
+// listEnd returns the LEFT or RIGHT argument of the LMPOP family
+func listEnd(fromLeft bool) string {
+	if fromLeft {
+		return "LEFT"
+	}
+	return "RIGHT"
+}
+
+// mpopReply parses the [key, [element ...]] reply of the LMPOP family,
+// ErrCacheMiss for the nil reply of empty lists
+func mpopReply(reply interface{}, err error) (string, [][]byte, error) {
+	values, err := redis.Values(reply, err)
+	if err == redis.ErrNil {
+		return "", nil, ErrCacheMiss
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	var key string
+	var items [][]byte
+	if _, err := redis.Scan(values, &key, &items); err != nil {
+		return "", nil, err
+	}
+	if len(items) == 0 {
+		return "", nil, ErrCacheMiss
+	}
+	return key, items, nil
+}
+
+// blockingSeconds converts timeout to the whole seconds accepted by blocking
+// commands, rounding up so a short timeout never turns into "block forever"
+func blockingSeconds(timeout time.Duration) int64 {
+	if timeout <= 0 {
+		return 0
+	}
+	return int64((timeout + time.Second - 1) / time.Second)
+}
+
+// blockingReadTimeout is the connection read timeout used while waiting on a
+// blocking command. Zero disables the read deadline.
+func blockingReadTimeout(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return 0
+	}
+	return time.Duration(blockingSeconds(timeout))*time.Second + blockingReadMargin
+}
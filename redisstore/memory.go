@@ -0,0 +1,248 @@
+package redisstore
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/owngoals/go-redis/serializer"
+)
+
+// MemoryStore is an in-process Store keeping serialized values in a map,
+// meant to stand in for RedisStore in tests
+type MemoryStore struct {
+	mu                sync.Mutex
+	items             map[string]memoryItem
+	defaultExpiration time.Duration
+	stop              chan struct{}
+	closeOnce         sync.Once
+}
+
+type memoryItem struct {
+	value   []byte
+	expires time.Time
+}
+
+func (i memoryItem) expired(now time.Time) bool {
+	return !i.expires.IsZero() && !now.Before(i.expires)
+}
+
+// NewMemoryStore returns a MemoryStore. When sweepInterval is positive a
+// background goroutine removes expired items at that interval until Close.
+func NewMemoryStore(defaultExpiration, sweepInterval time.Duration) *MemoryStore {
+	c := &MemoryStore{
+		items:             make(map[string]memoryItem),
+		defaultExpiration: defaultExpiration,
+		stop:              make(chan struct{}),
+	}
+	if sweepInterval > 0 {
+		go c.sweep(sweepInterval)
+	}
+	return c
+}
+
+// Close stops the background sweeper
+func (c *MemoryStore) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+	})
+	return nil
+}
+
+// Get (see CacheStore interface)
+func (c *MemoryStore) Get(key string, ptrValue interface{}) error {
+	c.mu.Lock()
+	item, ok := c.get(key)
+	c.mu.Unlock()
+	if !ok {
+		return ErrCacheMiss
+	}
+	return serializer.Deserialize(item.value, ptrValue)
+}
+
+// Set (see CacheStore interface)
+func (c *MemoryStore) Set(key string, value interface{}, expires time.Duration) error {
+	if serializer.IsNil(value) {
+		return ErrNilValue
+	}
+	b, err := serializer.Serialize(value)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, b, expires)
+	return nil
+}
+
+// Add (see CacheStore interface)
+func (c *MemoryStore) Add(key string, value interface{}, expires time.Duration) error {
+	if serializer.IsNil(value) {
+		return ErrNilValue
+	}
+	b, err := serializer.Serialize(value)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.get(key); ok {
+		return ErrNotStored
+	}
+	c.set(key, b, expires)
+	return nil
+}
+
+// Replace (see CacheStore interface)
+func (c *MemoryStore) Replace(key string, value interface{}, expires time.Duration) error {
+	if serializer.IsNil(value) {
+		return ErrNilValue
+	}
+	b, err := serializer.Serialize(value)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.get(key); !ok {
+		return ErrNotStored
+	}
+	c.set(key, b, expires)
+	return nil
+}
+
+// Delete (see CacheStore interface)
+func (c *MemoryStore) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.get(key); !ok {
+		return ErrCacheMiss
+	}
+	delete(c.items, key)
+	return nil
+}
+
+// Increment (see CacheStore interface)
+func (c *MemoryStore) Increment(key string, delta uint64) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.get(key)
+	if !ok {
+		return 0, ErrCacheMiss
+	}
+	current, err := strconv.ParseInt(string(item.value), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	sum := current + int64(delta)
+	item.value = []byte(strconv.FormatInt(sum, 10))
+	c.items[key] = item
+	return uint64(sum), nil
+}
+
+// Decrement (see CacheStore interface)
+func (c *MemoryStore) Decrement(key string, delta uint64) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.get(key)
+	if !ok {
+		return 0, ErrCacheMiss
+	}
+	current, err := strconv.ParseInt(string(item.value), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	// Decrement contract says you can only go to 0
+	next := current - int64(delta)
+	if delta > uint64(current) {
+		next = 0
+	}
+	item.value = []byte(strconv.FormatInt(next, 10))
+	c.items[key] = item
+	return uint64(next), nil
+}
+
+// Flush (see CacheStore interface)
+func (c *MemoryStore) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = make(map[string]memoryItem)
+	return nil
+}
+
+// Exists (see Store interface)
+func (c *MemoryStore) Exists(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.get(key)
+	return ok
+}
+
+// SetExpire (see Store interface)
+func (c *MemoryStore) SetExpire(key string, expires time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.get(key)
+	if !ok {
+		return false
+	}
+	if expires <= 0 {
+		delete(c.items, key)
+		return true
+	}
+	item.expires = time.Now().Add(expires)
+	c.items[key] = item
+	return true
+}
+
+// get returns the live item stored at key, c.mu must be held
+func (c *MemoryStore) get(key string) (memoryItem, bool) {
+	item, ok := c.items[key]
+	if !ok {
+		return item, false
+	}
+	if item.expired(time.Now()) {
+		delete(c.items, key)
+		return item, false
+	}
+	return item, true
+}
+
+// DefaultExpiration returns the expiration applied to writes passing DEFAULT
+func (c *MemoryStore) DefaultExpiration() time.Duration {
+	return c.defaultExpiration
+}
+
+// set stores value at key, c.mu must be held
+func (c *MemoryStore) set(key string, value []byte, expires time.Duration) {
+	switch expires {
+	case DEFAULT:
+		expires = c.defaultExpiration
+	case FOREVER:
+		expires = time.Duration(0)
+	}
+	item := memoryItem{value: value}
+	if expires > 0 {
+		item.expires = time.Now().Add(expires)
+	}
+	c.items[key] = item
+}
+
+func (c *MemoryStore) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case now := <-ticker.C:
+			c.mu.Lock()
+			for key, item := range c.items {
+				if item.expired(now) {
+					delete(c.items, key)
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package redisstore
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// ErrNotLFU is returned by ObjectFreq when the server maxmemory-policy is not an LFU one
+var ErrNotLFU = errors.New("cache: access frequency requires an LFU maxmemory-policy")
+
+// ObjectFreq returns the logarithmic access frequency counter of key
+func (c *RedisStore) ObjectFreq(key string) (int64, error) {
+	conn := c.conn()
+	defer conn.Close()
+	return objectFreq(conn, key)
+}
+
+// ScanFreq calls fn with every key matching pattern and its access frequency,
+// skipping keys gone before OBJECT FREQ reached them. Both commands share one
+// connection. Iteration stops at the first error returned by fn.
+func (c *RedisStore) ScanFreq(match string, fn func(key string, freq int64) error) error {
+	conn := c.conn()
+	defer conn.Close()
+	return scanKeys(conn, match, "", func(key string) error {
+		freq, err := objectFreq(conn, key)
+		if err == ErrCacheMiss {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return fn(key, freq)
+	})
+}
+
+func objectFreq(conn redis.Conn, key string) (int64, error) {
+	freq, err := redis.Int64(conn.Do("OBJECT", "FREQ", key))
+	if err == redis.ErrNil {
+		return 0, ErrCacheMiss
+	}
+	if e, ok := err.(redis.Error); ok && strings.Contains(string(e), "LFU") {
+		return 0, ErrNotLFU
+	}
+	return freq, err
+}
+
+// ObjectRefCount returns the number of references to the value of key, shared
+// integers report a very large count
+func (c *RedisStore) ObjectRefCount(key string) (int64, error) {
+	conn := c.conn()
+	defer conn.Close()
+	n, err := redis.Int64(conn.Do("OBJECT", "REFCOUNT", key))
+	if err == redis.ErrNil {
+		return 0, ErrCacheMiss
+	}
+	return n, err
+}
+
+// ObjectIdleTime returns how long key has not been accessed, in seconds
+// resolution. It fails when the server runs an LFU maxmemory-policy.
+func (c *RedisStore) ObjectIdleTime(key string) (time.Duration, error) {
+	conn := c.conn()
+	defer conn.Close()
+	secs, err := redis.Int64(conn.Do("OBJECT", "IDLETIME", key))
+	if err == redis.ErrNil {
+		return 0, ErrCacheMiss
+	}
+	return time.Duration(secs) * time.Second, err
+}
+
+// MarkAccessed records an access to keys with TOUCH, as reading them would, and
+// returns how many exist
+func (c *RedisStore) MarkAccessed(keys ...string) (int, error) {
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Int(conn.Do("TOUCH", redis.Args{}.AddFlat(keys)...))
+}
+
+// KeyInfo describes a key
+type KeyInfo struct {
+	// TTL is the remaining time to live, FOREVER when the key does not expire
+	TTL time.Duration
+	// Type is the Redis type of the value: string, list, set, zset, hash or stream
+	Type string
+	// Size is the number of bytes the key and its value take in memory
+	Size int64
+}
+
+// Inspect pipelines PTTL, TYPE and MEMORY USAGE to describe key in a single
+// round-trip, ErrCacheMiss when it does not exist
+func (c *RedisStore) Inspect(key string) (KeyInfo, error) {
+	conn := c.conn()
+	defer conn.Close()
+	conn.Send("PTTL", key)
+	conn.Send("TYPE", key)
+	conn.Send("MEMORY", "USAGE", key)
+	if err := conn.Flush(); err != nil {
+		return KeyInfo{}, err
+	}
+	ms, errTTL := redis.Int64(conn.Receive())
+	typeName, errType := redis.String(conn.Receive())
+	size, errSize := redis.Int64(conn.Receive())
+	if errTTL != nil {
+		return KeyInfo{}, errTTL
+	}
+	if errType != nil {
+		return KeyInfo{}, errType
+	}
+	if typeName == "none" || ms == -2 || errSize == redis.ErrNil {
+		return KeyInfo{}, ErrCacheMiss
+	}
+	if errSize != nil {
+		return KeyInfo{}, errSize
+	}
+	info := KeyInfo{TTL: time.Duration(ms) * time.Millisecond, Type: typeName, Size: size}
+	if ms == -1 {
+		info.TTL = FOREVER
+	}
+	return info, nil
+}
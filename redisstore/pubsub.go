@@ -0,0 +1,104 @@
+package redisstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/owngoals/go-redis/serializer"
+)
+
+// Publish sends the serialized message to channel and returns how many
+// subscribers received it
+func (c *RedisStore) Publish(channel string, message interface{}) (int, error) {
+	b, err := serializer.Serialize(message)
+	if err != nil {
+		return 0, err
+	}
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Int(conn.Do("PUBLISH", channel, b))
+}
+
+// Backoff between attempts to restore a failed subscription
+const (
+	subscribeBackoff    = 100 * time.Millisecond
+	subscribeMaxBackoff = 10 * time.Second
+)
+
+// Subscribe calls fn with the raw payload of every message published to channels
+// until ctx is done. It holds a pool connection meanwhile. When the connection
+// fails it reconnects with exponential backoff and subscribes again, reporting
+// the failure to onReconnect if set. Messages published while disconnected are
+// lost. Errors other than network failures end the subscription.
+func (c *RedisStore) Subscribe(ctx context.Context, channels []string, fn func(channel string, data []byte),
+	onReconnect func(err error)) error {
+	backoff := subscribeBackoff
+	for {
+		subscribed, err := c.subscribe(ctx, channels, fn)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil || !(Retryable(err) || err == ErrCircuitOpen) {
+			return err
+		}
+		if subscribed {
+			backoff = subscribeBackoff
+		}
+		if onReconnect != nil {
+			onReconnect(err)
+		}
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+		if backoff *= 2; backoff > subscribeMaxBackoff {
+			backoff = subscribeMaxBackoff
+		}
+	}
+}
+
+// subscribe runs a single subscription until ctx is done or the connection
+// fails, and reports whether the server confirmed it
+func (c *RedisStore) subscribe(ctx context.Context, channels []string, fn func(channel string, data []byte)) (bool, error) {
+	// the subscription is governed by ctx rather than by a store context deadline
+	sub := *c
+	sub.ctx = nil
+	psc := redis.PubSubConn{Conn: sub.conn()}
+	defer psc.Close()
+	if err := psc.Subscribe(redis.Args{}.AddFlat(channels)...); err != nil {
+		return false, err
+	}
+	subscribed := false
+	done := make(chan error, 1)
+	go func() {
+		for {
+			switch v := psc.Receive().(type) {
+			case redis.Message:
+				fn(v.Channel, v.Data)
+			case redis.Subscription:
+				if v.Kind == "subscribe" {
+					subscribed = true
+				}
+				if v.Count == 0 {
+					done <- nil
+					return
+				}
+			case error:
+				done <- v
+				return
+			}
+		}
+	}()
+	select {
+	case err := <-done:
+		return subscribed, err
+	case <-ctx.Done():
+		psc.Unsubscribe()
+		<-done
+		return subscribed, ctx.Err()
+	}
+}
@@ -0,0 +1,35 @@
+package redisstore
+
+import (
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// WaitForReplicas blocks until numReplicas replicas acknowledged the writes made
+// on the connection, or timeout elapses, and returns how many did. A zero timeout
+// blocks indefinitely.
+func (c *RedisStore) WaitForReplicas(numReplicas int, timeout time.Duration) (int, error) {
+	conn := c.conn()
+	defer conn.Close()
+	return wait(conn, numReplicas, timeout)
+}
+
+// SetAndWait is Set followed by WAIT on the same connection, returning how many
+// replicas acknowledged the write
+func (c *RedisStore) SetAndWait(key string, value interface{}, expires time.Duration, numReplicas int, timeout time.Duration) (int, error) {
+	conn := c.conn()
+	defer conn.Close()
+	if err := c.invoke(conn.Do, key, value, expires); err != nil {
+		return 0, err
+	}
+	return wait(conn, numReplicas, timeout)
+}
+
+func wait(conn redis.Conn, numReplicas int, timeout time.Duration) (int, error) {
+	var readTimeout time.Duration
+	if timeout > 0 {
+		readTimeout = timeout + blockingReadMargin
+	}
+	return redis.Int(redis.DoWithTimeout(conn, readTimeout, "WAIT", numReplicas, int64(timeout/time.Millisecond)))
+}
@@ -0,0 +1,221 @@
+package redisstore
+
+import (
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// scanCount is the COUNT hint sent with every SCAN iteration
+const scanCount = 100
+
+// Scan iterates the keys matching pattern, calling fn for each one.
+// Iteration stops at the first error returned by fn.
+func (c *RedisStore) Scan(match string, fn func(key string) error) error {
+	return c.ScanType(match, "", fn)
+}
+
+// ScanType is Scan restricted to keys of typeName (string, list, set, zset, hash, stream).
+// It relies on SCAN ... TYPE (Redis 6+) and falls back to a TYPE lookup per key
+// when the server rejects the argument.
+func (c *RedisStore) ScanType(match, typeName string, fn func(key string) error) error {
+	conn := c.conn()
+	defer conn.Close()
+	return scanKeys(conn, match, typeName, fn)
+}
+
+// scanKeys runs the SCAN loop of ScanType on conn. fn may send commands on conn
+// too, every SCAN reply being read before it is called: the pool can then be
+// capped to a single connection.
+func scanKeys(conn redis.Conn, match, typeName string, fn func(key string) error) error {
+	serverFilter := typeName != ""
+	var cursor int64
+	for {
+		args := redis.Args{cursor, "MATCH", match, "COUNT", scanCount}
+		if serverFilter {
+			args = args.Add("TYPE", typeName)
+		}
+		reply, err := redis.Values(conn.Do("SCAN", args...))
+		if _, ok := err.(redis.Error); ok && serverFilter && cursor == 0 {
+			serverFilter = false
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var keys []string
+		if _, err := redis.Scan(reply, &cursor, &keys); err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if typeName != "" && !serverFilter {
+				t, err := redis.String(conn.Do("TYPE", key))
+				if err != nil {
+					return err
+				}
+				if t != typeName {
+					continue
+				}
+			}
+			if err := fn(key); err != nil {
+				return err
+			}
+		}
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// HScan iterates the fields of the hash at key whose name matches pattern,
+// calling fn with each field and its raw value. Iteration stops at the first error returned by fn.
+func (c *RedisStore) HScan(key, match string, fn func(field string, value []byte) error) error {
+	return c.scanCollection("HSCAN", key, match, func(pair [][]byte) error {
+		return fn(string(pair[0]), pair[1])
+	}, 2)
+}
+
+// SScan iterates the members of the set at key matching pattern, in their stored form
+func (c *RedisStore) SScan(key, match string, fn func(member string) error) error {
+	return c.scanCollection("SSCAN", key, match, func(item [][]byte) error {
+		return fn(string(item[0]))
+	}, 1)
+}
+
+// ZScan iterates the members of the sorted set at key matching pattern, with their score
+func (c *RedisStore) ZScan(key, match string, fn func(member string, score float64) error) error {
+	return c.scanCollection("ZSCAN", key, match, func(pair [][]byte) error {
+		score, err := redis.Float64(pair[1], nil)
+		if err != nil {
+			return err
+		}
+		return fn(string(pair[0]), score)
+	}, 2)
+}
+
+// scanCollection runs the cursor loop of HSCAN, SSCAN and ZSCAN, calling fn
+// with each group of width elements of the replies. An empty match scans everything.
+func (c *RedisStore) scanCollection(cmd, key, match string, fn func(item [][]byte) error, width int) error {
+	conn := c.conn()
+	defer conn.Close()
+	var cursor int64
+	for {
+		args := redis.Args{key, cursor, "COUNT", scanCount}
+		if match != "" {
+			args = args.Add("MATCH", match)
+		}
+		reply, err := redis.Values(conn.Do(cmd, args...))
+		if err != nil {
+			return err
+		}
+		var items [][]byte
+		if _, err := redis.Scan(reply, &cursor, &items); err != nil {
+			return err
+		}
+		for i := 0; i+width <= len(items); i += width {
+			if err := fn(items[i : i+width]); err != nil {
+				return err
+			}
+		}
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// RandomKeys pipelines n RANDOMKEY commands and returns the keys drawn, which
+// may repeat. An empty database yields no keys.
+func (c *RedisStore) RandomKeys(n int) ([]string, error) {
+	conn := c.conn()
+	defer conn.Close()
+	for i := 0; i < n; i++ {
+		if err := conn.Send("RANDOMKEY"); err != nil {
+			return nil, err
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		key, err := redis.String(conn.Receive())
+		if err == redis.ErrNil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// DeleteMatching scans the keys matching pattern and deletes them in batches of
+// the delete batch size, returning how many were removed
+func (c *RedisStore) DeleteMatching(match string) (int, error) {
+	return c.DeleteMatchingProgress(match, 0, nil)
+}
+
+// DeleteMatchingProgress is DeleteMatching deleting at most maxPerSecond keys per
+// second, zero for no limit, and calling progress after every batch with the
+// running count of keys scanned and deleted
+func (c *RedisStore) DeleteMatchingProgress(match string, maxPerSecond int, progress func(scanned, deleted int)) (int, error) {
+	batchSize := c.deleteBatchSize()
+	if maxPerSecond > 0 && maxPerSecond < batchSize {
+		batchSize = maxPerSecond
+	}
+	conn := c.conn()
+	defer conn.Close()
+	var batch []string
+	scanned, deleted := 0, 0
+	start := time.Now()
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if maxPerSecond > 0 {
+			due := start.Add(time.Duration(deleted+len(batch)) * time.Second / time.Duration(maxPerSecond))
+			if err := c.sleep(time.Until(due)); err != nil {
+				return err
+			}
+		}
+		n, err := c.deleteKeys(conn, batch)
+		deleted += n
+		batch = batch[:0]
+		if err == nil && progress != nil {
+			progress(scanned, deleted)
+		}
+		return err
+	}
+	err := scanKeys(conn, match, "", func(key string) error {
+		scanned++
+		batch = append(batch, key)
+		if len(batch) < batchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err != nil {
+		return deleted, err
+	}
+	return deleted, flush()
+}
+
+// sleep waits for d, returning early with the error of the bound context when it is done
+func (c *RedisStore) sleep(d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	var done <-chan struct{}
+	if c.ctx != nil {
+		done = c.ctx.Done()
+	}
+	select {
+	case <-t.C:
+		return nil
+	case <-done:
+		return c.ctx.Err()
+	}
+}
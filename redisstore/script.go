@@ -0,0 +1,275 @@
+package redisstore
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/owngoals/go-redis/serializer"
+)
+
+var casScript = redis.NewScript(1, `
+local cur = redis.call("GET", KEYS[1])
+if not cur then
+	if ARGV[4] ~= "1" then return 0 end
+elseif cur ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+else
+	redis.call("SET", KEYS[1], ARGV[2])
+end
+return 1`)
+
+// CompareAndSwap sets key to new only if its serialized value equals old, and
+// reports whether the swap happened. A missing key is swapped when createMissing.
+func (c *RedisStore) CompareAndSwap(key string, old, new interface{}, expires time.Duration, createMissing bool) (bool, error) {
+	if serializer.IsNil(new) {
+		return false, ErrNilValue
+	}
+	oldb, err := serializer.Serialize(old)
+	if err != nil {
+		return false, err
+	}
+	newb, err := serializer.Serialize(new)
+	if err != nil {
+		return false, err
+	}
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Bool(casScript.Do(conn, key, oldb, newb, c.milliseconds(expires), createMissing))
+}
+
+var deleteIfEqualScript = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// DeleteIfEqual deletes key only if its serialized value equals value, and
+// reports whether it did. Locks release with it so that a holder whose lock
+// expired does not delete the lock taken since by another.
+func (c *RedisStore) DeleteIfEqual(key string, value interface{}) (bool, error) {
+	b, err := serializer.Serialize(value)
+	if err != nil {
+		return false, err
+	}
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Bool(deleteIfEqualScript.Do(conn, key, b))
+}
+
+var setIfChangedScript = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then return 0 end
+if tonumber(ARGV[2]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+else
+	redis.call("SET", KEYS[1], ARGV[1])
+end
+return 1`)
+
+// SetIfChanged sets key to value unless its serialized form equals the stored
+// value, and reports whether it wrote. An unchanged key keeps its expiration.
+func (c *RedisStore) SetIfChanged(key string, value interface{}, expires time.Duration) (bool, error) {
+	if serializer.IsNil(value) {
+		return false, ErrNilValue
+	}
+	b, err := serializer.Serialize(value)
+	if err != nil {
+		return false, err
+	}
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Bool(setIfChangedScript.Do(conn, key, b, c.milliseconds(expires)))
+}
+
+var heartbeatScript = redis.NewScript(1, `
+local created
+if tonumber(ARGV[2]) > 0 then
+	created = redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2], "NX")
+else
+	created = redis.call("SET", KEYS[1], ARGV[1], "NX")
+end
+if created then return 1 end
+if tonumber(ARGV[2]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// Heartbeat sets key to value with ttl if it does not exist, otherwise only
+// refreshes its expiration, and reports whether it created the key
+func (c *RedisStore) Heartbeat(key string, value interface{}, ttl time.Duration) (bool, error) {
+	if serializer.IsNil(value) {
+		return false, ErrNilValue
+	}
+	b, err := serializer.Serialize(value)
+	if err != nil {
+		return false, err
+	}
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Bool(heartbeatScript.Do(conn, key, b, c.milliseconds(ttl)))
+}
+
+var incrementModScript = redis.NewScript(1, `
+if ARGV[3] ~= "1" and redis.call("EXISTS", KEYS[1]) == 0 then return false end
+local n = redis.call("INCRBY", KEYS[1], ARGV[1])
+local modulus = tonumber(ARGV[2])
+if n < modulus then return n end
+n = n % modulus
+local ttl = redis.call("PTTL", KEYS[1])
+redis.call("SET", KEYS[1], string.format("%d", n))
+if ttl > 0 then redis.call("PEXPIRE", KEYS[1], ttl) end
+return n`)
+
+// maxModulus bounds the modulus of IncrementMod so that the counter plus the
+// delta, both below it, stays within the 2^53 integers Lua numbers hold exactly
+const maxModulus = 1 << 52
+
+// IncrementMod adds delta to the counter at key and wraps it to 0..modulus-1,
+// keeping its expiration. A missing counter is created at 0 when create is set,
+// ErrCacheMiss otherwise. modulus must be between 1 and 2^52, and the counter
+// below it, as IncrementMod leaves it; delta may be any uint64.
+func (c *RedisStore) IncrementMod(key string, delta, modulus uint64, create bool) (uint64, error) {
+	if modulus == 0 {
+		return 0, errors.New("cache: zero modulus")
+	}
+	if modulus > maxModulus {
+		return 0, errors.New("cache: modulus above 2^52")
+	}
+	conn := c.conn()
+	defer conn.Close()
+	n, err := redis.Int64(incrementModScript.Do(conn, key, delta%modulus, modulus, create))
+	if err == redis.ErrNil {
+		return 0, ErrCacheMiss
+	}
+	return uint64(n), err
+}
+
+var incrementExistingScript = redis.NewScript(1, `
+if redis.call("EXISTS", KEYS[1]) == 0 then return false end
+return redis.call("INCRBY", KEYS[1], ARGV[1])`)
+
+// versionTag starts values written by SetVersioned, followed by the decimal
+// version and ":". Like the serializer format tags, no gob stream or decimal
+// integer starts with it.
+const versionTag = 0xF8
+
+var setVersionedScript = redis.NewScript(2, `
+local mode = ARGV[3]
+local exists = redis.call("EXISTS", KEYS[1]) == 1
+if (mode == "NX" and exists) or (mode == "XX" and not exists) then return false end
+if mode == "CHANGED" or mode == "EQ" then
+	local cur = redis.call("GET", KEYS[1])
+	if cur and string.byte(cur) == 248 then
+		local i = string.find(cur, ":", 1, true)
+		if i then cur = string.sub(cur, i + 1) end
+	end
+	if mode == "CHANGED" and cur == ARGV[1] then return false end
+	if mode == "EQ" then
+		if not cur then
+			if ARGV[5] ~= "1" then return false end
+		elseif cur ~= ARGV[4] then
+			return false
+		end
+	end
+end
+local version = redis.call("INCR", KEYS[2])
+if version == 1 and exists then
+	local cur = redis.pcall("GET", KEYS[1])
+	if type(cur) == "string" and string.byte(cur) == 248 then
+		local i = string.find(cur, ":", 1, true)
+		local prev = i and tonumber(string.sub(cur, 2, i - 1))
+		if prev then
+			version = prev + 1
+			redis.call("SET", KEYS[2], version)
+		end
+	end
+end
+local value = "\248" .. version .. ":" .. ARGV[1]
+if tonumber(ARGV[2]) > 0 then
+	redis.call("SET", KEYS[1], value, "PX", ARGV[2])
+	redis.call("PEXPIRE", KEYS[2], ARGV[2])
+else
+	redis.call("SET", KEYS[1], value)
+	redis.call("PERSIST", KEYS[2])
+end
+return version`)
+
+// SetVersioned increments the counter at versionKey and stores value at key
+// prefixed with the new version, atomically, and returns the version. onlyIf
+// "NX" only writes a missing key, "XX" an existing one and "CHANGED" a value
+// differing from the stored one, others fail with ErrNotStored. The counter
+// expires with the value; one found missing while the value is still stored,
+// e.g. after its expiration was extended, resumes from the version of the value.
+func (c *RedisStore) SetVersioned(key, versionKey string, value interface{}, expires time.Duration, onlyIf string) (int64, error) {
+	if serializer.IsNil(value) {
+		return 0, ErrNilValue
+	}
+	b, err := serializer.Serialize(value)
+	if err != nil {
+		return 0, err
+	}
+	return c.setVersioned(key, versionKey, b, expires, onlyIf, nil, false)
+}
+
+// CompareAndSwapVersioned is CompareAndSwap for values written by SetVersioned:
+// old is compared to the stored value without its version, and new is stored
+// with the next version
+func (c *RedisStore) CompareAndSwapVersioned(key, versionKey string, old, new interface{}, expires time.Duration, createMissing bool) (bool, error) {
+	if serializer.IsNil(new) {
+		return false, ErrNilValue
+	}
+	oldb, err := serializer.Serialize(old)
+	if err != nil {
+		return false, err
+	}
+	newb, err := serializer.Serialize(new)
+	if err != nil {
+		return false, err
+	}
+	_, err = c.setVersioned(key, versionKey, newb, expires, "EQ", oldb, createMissing)
+	if err == ErrNotStored {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (c *RedisStore) setVersioned(key, versionKey string, b []byte, expires time.Duration, mode string, old []byte, createMissing bool) (int64, error) {
+	conn := c.conn()
+	defer conn.Close()
+	version, err := redis.Int64(setVersionedScript.Do(conn, key, versionKey, b, c.milliseconds(expires), mode, old, createMissing))
+	if err == redis.ErrNil {
+		return 0, ErrNotStored
+	}
+	return version, err
+}
+
+// Unversion splits a value written by SetVersioned into its version and
+// payload. Other values are returned whole with version 0.
+func Unversion(b []byte) (int64, []byte) {
+	if len(b) == 0 || b[0] != versionTag {
+		return 0, b
+	}
+	i := bytes.IndexByte(b, ':')
+	if i < 0 {
+		return 0, b
+	}
+	version, err := strconv.ParseInt(string(b[1:i]), 10, 64)
+	if err != nil {
+		return 0, b
+	}
+	return version, b[i+1:]
+}
+
+// milliseconds resolves expires to the milliseconds passed to scripts for PX,
+// rounded up so short expirations do not become 0, which means no expiration
+func (c *RedisStore) milliseconds(expires time.Duration) int64 {
+	if expires = c.expiration(expires); expires > 0 {
+		return int64((expires + time.Millisecond - 1) / time.Millisecond)
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+package redisstore
+
+import (
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// acquireScript scores holders by their expiration time, in server milliseconds,
+// so stale holders are dropped before counting the live ones
+var acquireScript = redis.NewScript(1, `
+redis.replicate_commands()
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local ttl = tonumber(ARGV[3])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now)
+if redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[2]) then return 0 end
+redis.call("ZADD", KEYS[1], now + ttl, ARGV[1])
+if redis.call("PTTL", KEYS[1]) < ttl then
+	redis.call("PEXPIRE", KEYS[1], ttl)
+end
+return 1`)
+
+// AcquireSemaphore adds token to the holders of the semaphore at key for ttl
+// unless limit live holders already hold it, and reports whether it did
+func (c *RedisStore) AcquireSemaphore(key, token string, limit int, ttl time.Duration) (bool, error) {
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Bool(acquireScript.Do(conn, key, token, limit, int64(ttl/time.Millisecond)))
+}
+
+// ReleaseSemaphore removes token from the holders of the semaphore at key
+func (c *RedisStore) ReleaseSemaphore(key, token string) error {
+	conn := c.conn()
+	defer conn.Close()
+	_, err := conn.Do("ZREM", key, token)
+	return err
+}
+
+// Lock sets key to token for ttl, rounded up to a whole millisecond, unless key
+// exists, and reports whether it did. DeleteIfEqual(key, token) releases it.
+func (c *RedisStore) Lock(key, token string, ttl time.Duration) (bool, error) {
+	conn := c.conn()
+	defer conn.Close()
+	ms := int64((ttl + time.Millisecond - 1) / time.Millisecond)
+	reply, err := conn.Do("SET", key, token, "NX", "PX", ms)
+	return reply != nil, err
+}
@@ -0,0 +1,130 @@
+package redisstore
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/owngoals/go-redis/serializer"
+)
+
+// SAdd adds members to the set stored at key and returns how many were new
+func (c *RedisStore) SAdd(key string, members ...interface{}) (int, error) {
+	conn := c.conn()
+	defer conn.Close()
+	args := redis.Args{key}
+	for _, member := range members {
+		b, err := serializer.Serialize(member)
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, b)
+	}
+	return redis.Int(conn.Do("SADD", args...))
+}
+
+// SMembers returns the members of the set stored at key, in their stored form
+func (c *RedisStore) SMembers(key string) ([]string, error) {
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Strings(conn.Do("SMEMBERS", key))
+}
+
+// SPop removes a random member of the set stored at key into ptrValue,
+// ErrCacheMiss when the set is empty
+func (c *RedisStore) SPop(key string, ptrValue interface{}) error {
+	return c.pop("SPOP", key, ptrValue)
+}
+
+// SMove atomically moves member from the set src to the set dst and reports
+// whether it was a member of src
+func (c *RedisStore) SMove(src, dst string, member interface{}) (bool, error) {
+	b, err := serializer.Serialize(member)
+	if err != nil {
+		return false, err
+	}
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Bool(conn.Do("SMOVE", src, dst, b))
+}
+
+// SInter returns the members present in every set
+func (c *RedisStore) SInter(keys ...string) ([]string, error) {
+	return c.setOp("SINTER", keys)
+}
+
+// SUnion returns the members present in any set
+func (c *RedisStore) SUnion(keys ...string) ([]string, error) {
+	return c.setOp("SUNION", keys)
+}
+
+// SDiff returns the members of the first set absent from all the others
+func (c *RedisStore) SDiff(keys ...string) ([]string, error) {
+	return c.setOp("SDIFF", keys)
+}
+
+// SInterStore stores the intersection of keys in dst and returns its size
+func (c *RedisStore) SInterStore(dst string, keys ...string) (int, error) {
+	return c.setOpStore("SINTERSTORE", dst, keys)
+}
+
+// SUnionStore stores the union of keys in dst and returns its size
+func (c *RedisStore) SUnionStore(dst string, keys ...string) (int, error) {
+	return c.setOpStore("SUNIONSTORE", dst, keys)
+}
+
+// SDiffStore stores the difference of keys in dst and returns its size
+func (c *RedisStore) SDiffStore(dst string, keys ...string) (int, error) {
+	return c.setOpStore("SDIFFSTORE", dst, keys)
+}
+
+// SInterCard returns the size of the intersection of keys, counting at most
+// limit members when limit is positive. Servers older than Redis 7 compute it
+// with SINTERSTORE into a temporary key next to keys[0], deleted in the same
+// transaction.
+func (c *RedisStore) SInterCard(keys []string, limit int) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	conn := c.conn()
+	defer conn.Close()
+	args := redis.Args{len(keys)}.AddFlat(keys)
+	if limit > 0 {
+		args = args.Add("LIMIT", limit)
+	}
+	n, err := redis.Int64(c.doOptional(conn, "SINTERCARD", args...))
+	if !unknownCommand(err) {
+		return n, err
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return 0, err
+	}
+	tmp := keys[0] + ":sintercard:" + hex.EncodeToString(b)
+	conn.Send("MULTI")
+	conn.Send("SINTERSTORE", redis.Args{tmp}.AddFlat(keys)...)
+	conn.Send("DEL", tmp)
+	reply, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return 0, err
+	}
+	if n, err = redis.Int64(reply[0], nil); err != nil {
+		return 0, err
+	}
+	if limit > 0 && n > int64(limit) {
+		n = int64(limit)
+	}
+	return n, nil
+}
+
+func (c *RedisStore) setOp(cmd string, keys []string) ([]string, error) {
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Strings(conn.Do(cmd, redis.Args{}.AddFlat(keys)...))
+}
+
+func (c *RedisStore) setOpStore(cmd, dst string, keys []string) (int, error) {
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Int(conn.Do(cmd, redis.Args{dst}.AddFlat(keys)...))
+}
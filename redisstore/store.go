@@ -1,9 +1,12 @@
 package redisstore
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/owngoals/go-redis/serializer"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gomodule/redigo/redis"
@@ -21,12 +24,26 @@ var (
 	ErrCacheMiss  = errors.New("cache: key not found")
 	ErrNotStored  = errors.New("cache: not stored")
 	ErrNotSupport = errors.New("cache: not support")
+	// ErrNilValue is returned when writing a nil value, which has no stored form
+	ErrNilValue = errors.New("cache: nil value")
+	// ErrPoolExhausted is returned when the pool has no connection to lend, at
+	// once when it does not wait or after the borrow timeout otherwise
+	ErrPoolExhausted = redis.ErrPoolExhausted
 )
 
 // RedisStore represents the cache with redis persistence
 type RedisStore struct {
 	pool              *redis.Pool
 	defaultExpiration time.Duration
+	ctx               context.Context
+	selectDB          bool
+	db, resetDB       int
+	breaker           *CircuitBreaker
+	drain             *drainer
+	deleteBatch       int
+	borrowTimeout     time.Duration
+	caps              *capabilities
+	intercept         Interceptor
 }
 
 // NewRedisCache returns a RedisStore
@@ -48,9 +65,12 @@ func NewRedisCache(host string, port int, password string, database int, default
 					return nil, err
 				}
 			}
-			if _, err := c.Do("SELECT", database); err != nil {
-				c.Close()
-				return nil, err
+			// connections start on database 0, the only one Redis Cluster accepts
+			if database != 0 {
+				if _, err := c.Do("SELECT", database); err != nil {
+					c.Close()
+					return nil, err
+				}
 			}
 			return c, nil
 		},
@@ -66,25 +86,25 @@ func NewRedisCache(host string, port int, password string, database int, default
 			return nil
 		},
 	}
-	return &RedisStore{pool, defaultExpiration}
+	return &RedisStore{pool: pool, defaultExpiration: defaultExpiration, drain: &drainer{}, caps: &capabilities{}}
 }
 
 // NewRedisCacheWithPool returns a RedisStore using the provided pool
 // until redigo supports sharding/clustering, only one host will be in hostList
 func NewRedisCacheWithPool(pool *redis.Pool, defaultExpiration time.Duration) *RedisStore {
-	return &RedisStore{pool, defaultExpiration}
+	return &RedisStore{pool: pool, defaultExpiration: defaultExpiration, drain: &drainer{}, caps: &capabilities{}}
 }
 
 // Set (see CacheStore interface)
 func (c *RedisStore) Set(key string, value interface{}, expires time.Duration) error {
-	conn := c.pool.Get()
+	conn := c.conn()
 	defer conn.Close()
 	return c.invoke(conn.Do, key, value, expires)
 }
 
 // Add (see CacheStore interface)
 func (c *RedisStore) Add(key string, value interface{}, expires time.Duration) error {
-	conn := c.pool.Get()
+	conn := c.conn()
 	defer conn.Close()
 	if exists(conn, key) {
 		return ErrNotStored
@@ -94,25 +114,25 @@ func (c *RedisStore) Add(key string, value interface{}, expires time.Duration) e
 
 // Replace (see CacheStore interface)
 func (c *RedisStore) Replace(key string, value interface{}, expires time.Duration) error {
-	conn := c.pool.Get()
+	if serializer.IsNil(value) {
+		return ErrNilValue
+	}
+	conn := c.conn()
 	defer conn.Close()
 	if !exists(conn, key) {
 		return ErrNotStored
 	}
-	err := c.invoke(conn.Do, key, value, expires)
-	if value == nil {
-		return ErrNotStored
-	}
-
-	return err
-
+	return c.invoke(conn.Do, key, value, expires)
 }
 
 // Get (see CacheStore interface)
 func (c *RedisStore) Get(key string, ptrValue interface{}) error {
-	conn := c.pool.Get()
+	conn := c.conn()
 	defer conn.Close()
 	raw, err := conn.Do("GET", key)
+	if err != nil {
+		return err
+	}
 	if raw == nil {
 		return ErrCacheMiss
 	}
@@ -123,13 +143,53 @@ func (c *RedisStore) Get(key string, ptrValue interface{}) error {
 	return serializer.Deserialize(item, ptrValue)
 }
 
+var getDelScript = redis.NewScript(1, `
+local v = redis.call("GET", KEYS[1])
+if v then redis.call("DEL", KEYS[1]) end
+return v`)
+
+// GetDel reads key into ptrValue and deletes it atomically, using GETDEL on
+// Redis 6.2+ and an equivalent script on older servers
+func (c *RedisStore) GetDel(key string, ptrValue interface{}) error {
+	conn := c.conn()
+	defer conn.Close()
+	item, err := redis.Bytes(c.doOptional(conn, "GETDEL", key))
+	if unknownCommand(err) {
+		item, err = redis.Bytes(getDelScript.Do(conn, key))
+	}
+	if err == redis.ErrNil {
+		return ErrCacheMiss
+	}
+	if err != nil {
+		return err
+	}
+	return serializer.Deserialize(item, ptrValue)
+}
+
+// unknownCommand reports whether err is the server rejecting a command it
+// does not implement, typically because it predates it
+func unknownCommand(err error) bool {
+	e, ok := err.(redis.Error)
+	return ok && strings.HasPrefix(string(e), "ERR unknown command")
+}
+
+// MGet returns the raw stored values of keys in order, nil for missing keys
+func (c *RedisStore) MGet(keys ...string) ([][]byte, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	conn := c.conn()
+	defer conn.Close()
+	return redis.ByteSlices(conn.Do("MGET", redis.Args{}.AddFlat(keys)...))
+}
+
 func exists(conn redis.Conn, key string) bool {
 	retval, _ := redis.Bool(conn.Do("EXISTS", key))
 	return retval
 }
 
 func (c *RedisStore) Exists(key string) bool {
-	conn := c.pool.Get()
+	conn := c.conn()
 	defer conn.Close()
 	b, err := redis.Bool(conn.Do("EXISTS", key))
 	if err != nil {
@@ -138,8 +198,49 @@ func (c *RedisStore) Exists(key string) bool {
 	return b
 }
 
+// TTL returns the remaining time to live of key, FOREVER when it has no
+// expiration and ErrCacheMiss when it does not exist
+func (c *RedisStore) TTL(key string) (time.Duration, error) {
+	conn := c.conn()
+	defer conn.Close()
+	ms, err := redis.Int64(conn.Do("PTTL", key))
+	if err != nil {
+		return 0, err
+	}
+	switch ms {
+	case -2:
+		return 0, ErrCacheMiss
+	case -1:
+		return FOREVER, nil
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// ExistsMulti pipelines an EXISTS per key and reports presence in the order of keys
+func (c *RedisStore) ExistsMulti(keys ...string) ([]bool, error) {
+	conn := c.conn()
+	defer conn.Close()
+	for _, key := range keys {
+		if err := conn.Send("EXISTS", key); err != nil {
+			return nil, err
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return nil, err
+	}
+	found := make([]bool, len(keys))
+	for i := range keys {
+		b, err := redis.Bool(conn.Receive())
+		if err != nil {
+			return nil, err
+		}
+		found[i] = b
+	}
+	return found, nil
+}
+
 func (c *RedisStore) SetExpire(key string, expires time.Duration) bool {
-	conn := c.pool.Get()
+	conn := c.conn()
 	defer conn.Close()
 	b, err := redis.Bool(conn.Do("EXPIRE", key, int32(expires/time.Second)))
 	if err != nil {
@@ -148,9 +249,127 @@ func (c *RedisStore) SetExpire(key string, expires time.Duration) bool {
 	return b
 }
 
+// ExpireFlag is a condition for ExpireWithFlag
+type ExpireFlag string
+
+const (
+	// ExpireNX sets the expiration only when the key has none
+	ExpireNX ExpireFlag = "NX"
+	// ExpireXX sets the expiration only when the key already has one
+	ExpireXX ExpireFlag = "XX"
+	// ExpireGT sets the expiration only when it is later than the current one
+	ExpireGT ExpireFlag = "GT"
+	// ExpireLT sets the expiration only when it is earlier than the current one
+	ExpireLT ExpireFlag = "LT"
+)
+
+// ExpireWithFlag sets the expiration of key when flag's condition holds and reports
+// whether it changed. It requires Redis 7, older servers yield ErrNotSupport.
+func (c *RedisStore) ExpireWithFlag(key string, expires time.Duration, flag ExpireFlag) (bool, error) {
+	conn := c.conn()
+	defer conn.Close()
+	b, err := redis.Bool(conn.Do("EXPIRE", key, int32(expires/time.Second), string(flag)))
+	if e, ok := err.(redis.Error); ok && strings.Contains(string(e), "wrong number of arguments") {
+		return false, ErrNotSupport
+	}
+	return b, err
+}
+
+// ExpireAt sets key to expire at the absolute time at and reports whether the key exists
+func (c *RedisStore) ExpireAt(key string, at time.Time) (bool, error) {
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Bool(conn.Do("PEXPIREAT", key, at.UnixNano()/int64(time.Millisecond)))
+}
+
+// ServerTime returns the current time of the server clock
+func (c *RedisStore) ServerTime() (time.Time, error) {
+	conn := c.conn()
+	defer conn.Close()
+	reply, err := redis.Int64s(conn.Do("TIME"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(reply) != 2 {
+		return time.Time{}, fmt.Errorf("redisstore: unexpected TIME reply %v", reply)
+	}
+	return time.Unix(reply[0], reply[1]*int64(time.Microsecond)), nil
+}
+
+// Touch resets the expiration of key without rewriting its value. FOREVER
+// removes the expiration. Returns false when the key does not exist.
+func (c *RedisStore) Touch(key string, expires time.Duration) bool {
+	conn := c.conn()
+	defer conn.Close()
+	cmd, args := c.touchArgs(key, expires)
+	b, err := redis.Bool(conn.Do(cmd, args...))
+	if err != nil {
+		return false
+	}
+	return b
+}
+
+// GetAndTouch reads key into ptrValue and resets its expiration in one pipeline
+func (c *RedisStore) GetAndTouch(key string, ptrValue interface{}, expires time.Duration) error {
+	conn := c.conn()
+	defer conn.Close()
+	cmd, args := c.touchArgs(key, expires)
+	conn.Send("GET", key)
+	conn.Send(cmd, args...)
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+	item, err := redis.Bytes(conn.Receive())
+	if _, terr := conn.Receive(); err == nil {
+		err = terr
+	}
+	if err == redis.ErrNil {
+		return ErrCacheMiss
+	}
+	if err != nil {
+		return err
+	}
+	return serializer.Deserialize(item, ptrValue)
+}
+
+func (c *RedisStore) touchArgs(key string, expires time.Duration) (string, redis.Args) {
+	if expires = c.expiration(expires); expires > 0 {
+		return "EXPIRE", redis.Args{key, int32(expires / time.Second)}
+	}
+	return "PERSIST", redis.Args{key}
+}
+
+// SetRange overwrites the stored bytes of key from offset with data and returns
+// the new length. It works on raw bytes, bypassing the serializer.
+func (c *RedisStore) SetRange(key string, offset int, data []byte) (int, error) {
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Int(conn.Do("SETRANGE", key, offset, data))
+}
+
+// Copy duplicates src into dst (Redis 6.2+), overwriting an existing dst only
+// when replace is set, and reports whether it copied. Returns ErrCacheMiss when
+// src does not exist.
+func (c *RedisStore) Copy(src, dst string, replace bool) (bool, error) {
+	conn := c.conn()
+	defer conn.Close()
+	args := redis.Args{src, dst}
+	if replace {
+		args = args.Add("REPLACE")
+	}
+	copied, err := redis.Bool(conn.Do("COPY", args...))
+	if err != nil || copied {
+		return copied, err
+	}
+	if !exists(conn, src) {
+		return false, ErrCacheMiss
+	}
+	return false, nil
+}
+
 // Delete (see CacheStore interface)
 func (c *RedisStore) Delete(key string) error {
-	conn := c.pool.Get()
+	conn := c.conn()
 	defer conn.Close()
 	if !exists(conn, key) {
 		return ErrCacheMiss
@@ -159,9 +378,119 @@ func (c *RedisStore) Delete(key string) error {
 	return err
 }
 
+// DeleteIgnoreMissing removes key with a single DEL, whether or not it existed
+func (c *RedisStore) DeleteIgnoreMissing(key string) error {
+	conn := c.conn()
+	defer conn.Close()
+	_, err := conn.Do("DEL", key)
+	return err
+}
+
+// defaultDeleteBatch is the number of keys per DEL command of bulk deletions
+const defaultDeleteBatch = 500
+
+// WithDeleteBatch returns a shallow copy of the store whose bulk deletions send
+// at most n keys per DEL command
+func (c *RedisStore) WithDeleteBatch(n int) *RedisStore {
+	cp := *c
+	cp.deleteBatch = n
+	return &cp
+}
+
+func (c *RedisStore) deleteBatchSize() int {
+	if c.deleteBatch > 0 {
+		return c.deleteBatch
+	}
+	return defaultDeleteBatch
+}
+
+// DeleteMulti removes all keys and returns how many existed. Keys are sent in
+// pipelined DEL commands of at most the delete batch size each, so no single
+// command grows large enough to stall the server.
+func (c *RedisStore) DeleteMulti(keys ...string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	conn := c.conn()
+	defer conn.Close()
+	return c.deleteKeys(conn, keys)
+}
+
+// deleteKeys pipelines the DEL batches of DeleteMulti on conn
+func (c *RedisStore) deleteKeys(conn redis.Conn, keys []string) (int, error) {
+	size := c.deleteBatchSize()
+	batches := 0
+	for start := 0; start < len(keys); start += size {
+		end := start + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		if err := conn.Send("DEL", redis.Args{}.AddFlat(keys[start:end])...); err != nil {
+			return 0, err
+		}
+		batches++
+	}
+	if err := conn.Flush(); err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for i := 0; i < batches; i++ {
+		n, err := redis.Int(conn.Receive())
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+	}
+	return deleted, nil
+}
+
+// IncrementMulti pipelines an INCRBY per key and returns the new values. Missing
+// keys are created from 0 when create is set, and left out otherwise. Keys that
+// failed, e.g. holding a non integer value, are left out and the first error is
+// returned.
+func (c *RedisStore) IncrementMulti(deltas map[string]int64, create bool) (map[string]int64, error) {
+	if len(deltas) == 0 {
+		return map[string]int64{}, nil
+	}
+	conn := c.conn()
+	defer conn.Close()
+	keys := make([]string, 0, len(deltas))
+	for key, delta := range deltas {
+		var err error
+		if create {
+			err = conn.Send("INCRBY", key, delta)
+		} else {
+			err = incrementExistingScript.Send(conn, key, delta)
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err := conn.Flush(); err != nil {
+		return nil, err
+	}
+	values := make(map[string]int64, len(keys))
+	var firstErr error
+	for _, key := range keys {
+		n, err := redis.Int64(conn.Receive())
+		if err == redis.ErrNil {
+			continue
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		values[key] = n
+	}
+	return values, firstErr
+}
+
 // Increment (see CacheStore interface)
 func (c *RedisStore) Increment(key string, delta uint64) (uint64, error) {
-	conn := c.pool.Get()
+	conn := c.conn()
 	defer conn.Close()
 	// Check for existance *before* increment as per the cache contract.
 	// redis will auto create the key, and we don't want that. Since we need to do increment
@@ -189,7 +518,7 @@ func (c *RedisStore) Increment(key string, delta uint64) (uint64, error) {
 
 // Decrement (see CacheStore interface)
 func (c *RedisStore) Decrement(key string, delta uint64) (newValue uint64, err error) {
-	conn := c.pool.Get()
+	conn := c.conn()
 	defer conn.Close()
 	// Check for existance *before* increment as per the cache contract.
 	// redis will auto create the key, and we don't want that, hence the exists call
@@ -210,22 +539,82 @@ func (c *RedisStore) Decrement(key string, delta uint64) (newValue uint64, err e
 
 // Flush (see CacheStore interface)
 func (c *RedisStore) Flush() error {
-	conn := c.pool.Get()
+	conn := c.conn()
 	defer conn.Close()
 	// 這裏修改為 flushdb
 	_, err := conn.Do("FLUSHDB")
 	return err
 }
 
+// AddMulti pipelines a SET ... NX for every item and returns the keys that were
+// actually created, keys that already existed are left untouched
+func (c *RedisStore) AddMulti(items map[string]interface{}, expires time.Duration) ([]string, error) {
+	expires = c.expiration(expires)
+	// serialize every item before sending anything, so an invalid one writes none
+	keys := make([]string, 0, len(items))
+	values := make([][]byte, 0, len(items))
+	for key, value := range items {
+		if serializer.IsNil(value) {
+			return nil, ErrNilValue
+		}
+		b, err := serializer.Serialize(value)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		values = append(values, b)
+	}
+	conn := c.conn()
+	defer conn.Close()
+	for i, key := range keys {
+		args := redis.Args{key, values[i], "NX"}
+		if expires > 0 {
+			args = append(args, "EX", int32(expires/time.Second))
+		}
+		if err := conn.Send("SET", args...); err != nil {
+			return nil, err
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return nil, err
+	}
+	var set []string
+	for _, key := range keys {
+		reply, err := conn.Receive()
+		if err != nil {
+			return set, err
+		}
+		if reply != nil {
+			set = append(set, key)
+		}
+	}
+	return set, nil
+}
+
+// DefaultExpiration returns the expiration applied to writes passing DEFAULT
+func (c *RedisStore) DefaultExpiration() time.Duration {
+	return c.defaultExpiration
+}
+
+// expiration resolves the DEFAULT and FOREVER sentinels to the TTL to write,
+// where 0 means no expiration. FOREVER never picks up the default expiration.
+func (c *RedisStore) expiration(expires time.Duration) time.Duration {
+	if expires == DEFAULT {
+		expires = c.defaultExpiration
+	}
+	if expires == FOREVER {
+		return time.Duration(0)
+	}
+	return expires
+}
+
 func (c *RedisStore) invoke(f func(string, ...interface{}) (interface{}, error),
 	key string, value interface{}, expires time.Duration) error {
 
-	switch expires {
-	case DEFAULT:
-		expires = c.defaultExpiration
-	case FOREVER:
-		expires = time.Duration(0)
+	if serializer.IsNil(value) {
+		return ErrNilValue
 	}
+	expires = c.expiration(expires)
 
 	b, err := serializer.Serialize(value)
 	if err != nil {
@@ -0,0 +1,118 @@
+package redisstore
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/owngoals/go-redis/serializer"
+)
+
+// StreamEntry is an entry of a stream with its raw field values
+type StreamEntry struct {
+	ID     string
+	Fields map[string][]byte
+}
+
+// XAdd appends an entry with the serialized fields to the stream stored at key
+// and returns its generated ID
+func (c *RedisStore) XAdd(key string, fields map[string]interface{}) (string, error) {
+	args := redis.Args{key, "*"}
+	for field, value := range fields {
+		b, err := serializer.Serialize(value)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, field, b)
+	}
+	conn := c.conn()
+	defer conn.Close()
+	return redis.String(conn.Do("XADD", args...))
+}
+
+// XRead returns up to count entries of the stream stored at key with an ID
+// greater than lastID, "0" reads from the start
+func (c *RedisStore) XRead(key, lastID string, count int) ([]StreamEntry, error) {
+	conn := c.conn()
+	defer conn.Close()
+	return streamEntries(conn.Do("XREAD", "COUNT", count, "STREAMS", key, lastID))
+}
+
+// XGroupCreate creates the consumer group of the stream stored at key, and the
+// stream when missing, delivering entries after startID ("$" for new entries
+// only). Creating an existing group is a no-op.
+func (c *RedisStore) XGroupCreate(key, group, startID string) error {
+	conn := c.conn()
+	defer conn.Close()
+	_, err := conn.Do("XGROUP", "CREATE", key, group, startID, "MKSTREAM")
+	if e, ok := err.(redis.Error); ok && strings.HasPrefix(string(e), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// XReadGroup reads up to count entries never delivered to the group on behalf
+// of consumer, waiting up to block for some when there are none. A zero block
+// returns immediately.
+func (c *RedisStore) XReadGroup(key, group, consumer string, count int, block time.Duration) ([]StreamEntry, error) {
+	conn := c.conn()
+	defer conn.Close()
+	args := redis.Args{"GROUP", group, consumer, "COUNT", count}
+	var timeout time.Duration
+	if block > 0 {
+		ms := int64((block + time.Millisecond - 1) / time.Millisecond)
+		args = args.Add("BLOCK", ms)
+		timeout = time.Duration(ms)*time.Millisecond + blockingReadMargin
+	}
+	args = args.Add("STREAMS", key, ">")
+	return streamEntries(redis.DoWithTimeout(conn, timeout, "XREADGROUP", args...))
+}
+
+// XAck acknowledges the entries ids of the group and returns how many were pending
+func (c *RedisStore) XAck(key, group string, ids ...string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Int(conn.Do("XACK", redis.Args{key, group}.AddFlat(ids)...))
+}
+
+// streamEntries parses the entries of the single stream of an XREAD or
+// XREADGROUP reply, a nil reply has none
+func streamEntries(reply interface{}, err error) ([]StreamEntry, error) {
+	streams, err := redis.Values(reply, err)
+	if err == redis.ErrNil || len(streams) == 0 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	stream, err := redis.Values(streams[0], nil)
+	if err != nil {
+		return nil, err
+	}
+	var name string
+	var items []interface{}
+	if _, err := redis.Scan(stream, &name, &items); err != nil {
+		return nil, err
+	}
+	entries := make([]StreamEntry, 0, len(items))
+	for _, item := range items {
+		fields, err := redis.Values(item, nil)
+		if err != nil {
+			return nil, err
+		}
+		var entry StreamEntry
+		var values [][]byte
+		if _, err := redis.Scan(fields, &entry.ID, &values); err != nil {
+			return nil, err
+		}
+		entry.Fields = make(map[string][]byte, len(values)/2)
+		for i := 0; i+1 < len(values); i += 2 {
+			entry.Fields[string(values[i])] = values[i+1]
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
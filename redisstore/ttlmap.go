@@ -0,0 +1,100 @@
+package redisstore
+
+import (
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/owngoals/go-redis/serializer"
+)
+
+// Fields expire natively with HPEXPIRE on Redis 7.4+. Older servers reject it,
+// the scripts then record the expiration time of the field, in server
+// milliseconds, in a sidecar sorted set: reads hide expired fields and
+// ReapFields deletes them.
+
+var hsetTTLScript = redis.NewScript(2, `
+redis.replicate_commands()
+redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+if pcall(redis.call, "HPEXPIRE", KEYS[1], ARGV[3], "FIELDS", 1, ARGV[1]) then
+	redis.call("ZREM", KEYS[2], ARGV[1])
+	return 1
+end
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call("ZADD", KEYS[2], now + tonumber(ARGV[3]), ARGV[1])
+return 0`)
+
+var hgetLiveScript = redis.NewScript(2, `
+redis.replicate_commands()
+local v = redis.call("HGET", KEYS[1], ARGV[1])
+if not v then return false end
+local expires = redis.call("ZSCORE", KEYS[2], ARGV[1])
+if expires then
+	local t = redis.call("TIME")
+	if tonumber(expires) <= tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000) then
+		redis.call("HDEL", KEYS[1], ARGV[1])
+		redis.call("ZREM", KEYS[2], ARGV[1])
+		return false
+	end
+end
+return v`)
+
+var reapFieldsScript = redis.NewScript(2, `
+redis.replicate_commands()
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local fields = redis.call("ZRANGEBYSCORE", KEYS[2], "-inf", now, "LIMIT", 0, tonumber(ARGV[1]))
+for _, field in ipairs(fields) do
+	redis.call("HDEL", KEYS[1], field)
+	redis.call("ZREM", KEYS[2], field)
+end
+return #fields`)
+
+// HSetTTL sets field of the hash at key to value for ttl, tracking the expiration
+// in the sorted set at expirations when the server cannot expire fields. It
+// reports whether the server expires the field natively.
+func (c *RedisStore) HSetTTL(key, expirations, field string, value interface{}, ttl time.Duration) (bool, error) {
+	b, err := serializer.Serialize(value)
+	if err != nil {
+		return false, err
+	}
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Bool(hsetTTLScript.Do(conn, key, expirations, field, b, int64(ttl/time.Millisecond)))
+}
+
+// HGetTTL is HGet treating a field expired according to expirations as absent
+func (c *RedisStore) HGetTTL(key, expirations, field string, ptrValue interface{}) error {
+	conn := c.conn()
+	defer conn.Close()
+	item, err := redis.Bytes(hgetLiveScript.Do(conn, key, expirations, field))
+	if err == redis.ErrNil {
+		return ErrCacheMiss
+	}
+	if err != nil {
+		return err
+	}
+	return serializer.Deserialize(item, ptrValue)
+}
+
+// HDelTTL removes field from the hash at key and its expiration from expirations
+func (c *RedisStore) HDelTTL(key, expirations, field string) (bool, error) {
+	conn := c.conn()
+	defer conn.Close()
+	conn.Send("MULTI")
+	conn.Send("HDEL", key, field)
+	conn.Send("ZREM", expirations, field)
+	reply, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return false, err
+	}
+	return redis.Bool(reply[0], nil)
+}
+
+// ReapFields deletes up to max fields of the hash at key expired according to
+// expirations and returns how many it deleted
+func (c *RedisStore) ReapFields(key, expirations string, max int) (int, error) {
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Int(reapFieldsScript.Do(conn, key, expirations, max))
+}
@@ -0,0 +1,77 @@
+package redisstore
+
+import (
+	"github.com/gomodule/redigo/redis"
+	"github.com/owngoals/go-redis/serializer"
+)
+
+// ZAddFlag is an option of ZAdd
+type ZAddFlag string
+
+const (
+	// ZAddNX only adds new members, never updating existing ones
+	ZAddNX ZAddFlag = "NX"
+	// ZAddXX only updates existing members, never adding new ones
+	ZAddXX ZAddFlag = "XX"
+	// ZAddGT only updates a score when the new one is greater (Redis 6.2+)
+	ZAddGT ZAddFlag = "GT"
+	// ZAddLT only updates a score when the new one is lower (Redis 6.2+)
+	ZAddLT ZAddFlag = "LT"
+	// ZAddCH counts updated members in the result, not only added ones
+	ZAddCH ZAddFlag = "CH"
+)
+
+// ZAdd sets the score of the serialized member in the sorted set stored at key
+// and returns how many members were added, or changed with ZAddCH
+func (c *RedisStore) ZAdd(key string, score float64, member interface{}, flags ...ZAddFlag) (int, error) {
+	b, err := serializer.Serialize(member)
+	if err != nil {
+		return 0, err
+	}
+	args := redis.Args{key}
+	for _, flag := range flags {
+		args = append(args, string(flag))
+	}
+	args = args.Add(score, b)
+	conn := c.conn()
+	defer conn.Close()
+	return redis.Int(conn.Do("ZADD", args...))
+}
+
+var zincrbyExistingScript = redis.NewScript(1, `
+if not redis.call("ZSCORE", KEYS[1], ARGV[2]) then return false end
+return redis.call("ZINCRBY", KEYS[1], ARGV[1], ARGV[2])`)
+
+// ZIncrBy adds increment to the score of member and returns the new score. A
+// missing member is created at 0 when create is set, ErrCacheMiss otherwise.
+func (c *RedisStore) ZIncrBy(key string, increment float64, member interface{}, create bool) (float64, error) {
+	b, err := serializer.Serialize(member)
+	if err != nil {
+		return 0, err
+	}
+	conn := c.conn()
+	defer conn.Close()
+	if create {
+		return redis.Float64(conn.Do("ZINCRBY", key, increment, b))
+	}
+	score, err := redis.Float64(zincrbyExistingScript.Do(conn, key, increment, b))
+	if err == redis.ErrNil {
+		return 0, ErrCacheMiss
+	}
+	return score, err
+}
+
+// ZScore returns the score of member, ErrCacheMiss when it is not in the set
+func (c *RedisStore) ZScore(key string, member interface{}) (float64, error) {
+	b, err := serializer.Serialize(member)
+	if err != nil {
+		return 0, err
+	}
+	conn := c.conn()
+	defer conn.Close()
+	score, err := redis.Float64(conn.Do("ZSCORE", key, b))
+	if err == redis.ErrNil {
+		return 0, ErrCacheMiss
+	}
+	return score, err
+}
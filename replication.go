@@ -0,0 +1,21 @@
+package goredis
+
+import "time"
+
+// WaitForReplicas wraps WAIT, returning how many replicas acknowledged the writes
+// made so far. WAIT only covers writes sent on the same connection: the pool
+// hands back the most recently used connection, so this holds for sequential
+// use, but concurrent callers should prefer SetAndWait.
+func (s *Service) WaitForReplicas(numReplicas int, timeout time.Duration) (int, error) {
+	return s.store.WaitForReplicas(numReplicas, timeout)
+}
+
+// SetAndWait sets key and waits up to timeout for numReplicas replicas to
+// acknowledge it, returning how many did
+func (s *Service) SetAndWait(key string, value interface{}, expires time.Duration, numReplicas int, timeout time.Duration) (int, error) {
+	value, err := s.encode(value)
+	if err != nil {
+		return 0, err
+	}
+	return s.store.SetAndWait(s.cacheKey(key), value, s.jittered(expires), numReplicas, timeout)
+}
@@ -0,0 +1,40 @@
+package goredis
+
+import (
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+// RetryPolicy retries idempotent operations that failed to reach Redis, or that
+// were rejected with an OOM or READONLY error which can clear up. Reads and
+// SET are idempotent; Increment-style writes, Add and scripts never retry since a
+// lost reply does not tell whether they were applied.
+type RetryPolicy struct {
+	// Attempts is the total number of attempts, the first one included
+	Attempts int
+	// Backoff is the wait before the first retry, doubled before each following one
+	Backoff time.Duration
+}
+
+func (p RetryPolicy) do(fn func() error) error {
+	err := fn()
+	backoff := p.Backoff
+	for i := 1; i < p.Attempts && transient(err); i++ {
+		time.Sleep(backoff)
+		backoff *= 2
+		err = fn()
+	}
+	return err
+}
+
+// transient reports whether err is a failure worth retrying
+func transient(err error) bool {
+	return redisstore.Retryable(err)
+}
+
+// Idempotent runs fn under the retry policy of the Service, or the one passed with
+// WithRetryPolicy. fn must be safe to run more than once.
+func (s *Service) Idempotent(fn func() error, opts ...CallOption) error {
+	return s.callOptions(opts).retry.do(fn)
+}
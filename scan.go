@@ -0,0 +1,187 @@
+package goredis
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Scan calls fn for every key under the prefix matching pattern, with the prefix
+// stripped. Keys written through a key hasher are yielded hashed.
+func (s *Service) Scan(pattern string, fn func(key string) error) error {
+	return s.ScanType(pattern, "", fn)
+}
+
+// ScanType is Scan restricted to keys of the given Redis type, e.g. "hash"
+func (s *Service) ScanType(pattern, typeName string, fn func(key string) error) error {
+	return s.store.ScanType(escapeGlob(s.prefix)+":"+pattern, typeName, func(key string) error {
+		return fn(s.stripKey(key))
+	})
+}
+
+// ScanStream is Scan delivering keys over a channel. Both channels are closed
+// once the scan completes, fails or ctx is cancelled; a terminal error, including
+// the ctx error, is sent on the error channel first.
+func (s *Service) ScanStream(ctx context.Context, pattern string) (<-chan string, <-chan error) {
+	keys := make(chan string)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(keys)
+		err := s.WithContext(ctx).Scan(pattern, func(key string) error {
+			select {
+			case keys <- key:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return keys, errs
+}
+
+// sampleDraws is the number of RANDOMKEY draws per requested key made by Sample
+const sampleDraws = 4
+
+// errSampled stops the SCAN of Sample once enough keys were collected
+var errSampled = errors.New("goredis: sample complete")
+
+// Sample returns up to n distinct keys under the prefix, picked at random with
+// RANDOMKEY. Draws outside the prefix and duplicates are discarded, and when
+// too few keys remain the sample is completed with the first keys of a SCAN,
+// so it is an approximation of a uniform sample, biased when the prefix holds a
+// small share of the database. n <= 0 returns no keys.
+func (s *Service) Sample(n int) ([]string, error) {
+	if n <= 0 {
+		return []string{}, nil
+	}
+	drawn, err := s.store.RandomKeys(n * sampleDraws)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, n)
+	keys := make([]string, 0, n)
+	add := func(key string) {
+		if len(keys) < n && !seen[key] {
+			seen[key] = true
+			keys = append(keys, s.stripKey(key))
+		}
+	}
+	for _, key := range drawn {
+		if strings.HasPrefix(key, s.prefix+":") {
+			add(key)
+		}
+	}
+	if len(keys) == n {
+		return keys, nil
+	}
+	err = s.store.Scan(escapeGlob(s.prefix)+":*", func(key string) error {
+		add(key)
+		if len(keys) == n {
+			return errSampled
+		}
+		return nil
+	})
+	if err != nil && err != errSampled {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// DeleteOption configures DeleteByPrefix
+type DeleteOption func(*deleteOptions)
+
+type deleteOptions struct {
+	maxPerSecond int
+	batchSize    int
+	progress     func(scanned, deleted int)
+}
+
+// WithDeleteProgress calls fn after every deleted batch with the running count
+// of keys scanned and deleted
+func WithDeleteProgress(fn func(scanned, deleted int)) DeleteOption {
+	return func(o *deleteOptions) {
+		o.progress = fn
+	}
+}
+
+// WithDeleteRateLimit caps the deletion rate to maxPerSecond keys per second so
+// a bulk purge does not monopolise the server
+func WithDeleteRateLimit(maxPerSecond int) DeleteOption {
+	return func(o *deleteOptions) {
+		o.maxPerSecond = maxPerSecond
+	}
+}
+
+// WithDeleteBatchSize deletes keys in DEL commands of at most n keys, 500 by default
+func WithDeleteBatchSize(n int) DeleteOption {
+	return func(o *deleteOptions) {
+		o.batchSize = n
+	}
+}
+
+// DeleteByPrefix deletes every key under the Service prefix starting with prefix
+// and returns how many were removed, not counting their WithEncodings copies and
+// WithVersioning counters deleted after them. Keys are found with SCAN, so keys written concurrently may
+// survive.
+func (s *Service) DeleteByPrefix(prefix string, opts ...DeleteOption) (int, error) {
+	var o deleteOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	store := s.store.WithDeleteBatch(o.batchSize)
+	match := escapeGlob(s.prefix) + ":" + escapeGlob(prefix) + "*"
+	n, err := store.DeleteMatchingProgress(match, o.maxPerSecond, o.progress)
+	if err != nil {
+		return n, err
+	}
+	if s.hashTag == nil {
+		// reservedKey wraps untagged keys in a hash tag
+		match = "{" + match
+	}
+	kinds := make([]string, 0, len(s.encodings)+1)
+	for _, format := range s.encodings {
+		kinds = append(kinds, format.String())
+	}
+	if s.versioned {
+		kinds = append(kinds, "version")
+	}
+	for _, kind := range kinds {
+		if _, err := store.DeleteMatchingProgress(reservedNamespace+":"+escapeGlob(kind)+":"+match, o.maxPerSecond, nil); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// FlushPrefix deletes every key under the Service prefix, leaving the rest of the
+// database alone, and returns how many were removed
+func (s *Service) FlushPrefix() (int, error) {
+	return s.DeleteByPrefix("")
+}
+
+// escapeGlob escapes the characters SCAN MATCH treats as a pattern
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (s *Service) stripKey(key string) string {
+	key = strings.TrimPrefix(key, s.prefix+":")
+	if s.hashTag != nil && strings.HasPrefix(key, "{") {
+		if i := strings.Index(key, "}:"); i >= 0 {
+			key = key[i+2:]
+		}
+	}
+	return key
+}
@@ -0,0 +1,167 @@
+package goredis
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestService_ScanType(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.DeleteMulti("scan:string", "scan:list")
+	if err := s.Set("scan:string", "hello", 1*time.Minute); err != nil {
+		t.FailNow()
+	}
+	if _, err := s.LPush("scan:list", "hello"); err != nil {
+		t.FailNow()
+	}
+	var keys []string
+	err := s.ScanType("scan:*", "list", func(key string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil || len(keys) != 1 || keys[0] != "scan:list" {
+		t.FailNow()
+	}
+}
+
+func TestService_ScanStream(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.DeleteMulti("stream:a", "stream:b")
+	for _, key := range []string{"stream:a", "stream:b"} {
+		if err := s.Set(key, key, 1*time.Minute); err != nil {
+			t.FailNow()
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	keys, errs := s.ScanStream(ctx, "stream:*")
+	<-keys
+	cancel()
+	for range keys {
+	}
+	if err := <-errs; err != nil && err != context.Canceled {
+		t.FailNow()
+	}
+}
+
+func TestService_FlushPrefix(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix+"_flush")
+	other := NewService(p, testPrefix)
+	defer other.Delete("kept")
+	if err := other.Set("kept", 1, time.Minute); err != nil {
+		t.FailNow()
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if err := s.Set(key, 1, time.Minute); err != nil {
+			t.FailNow()
+		}
+	}
+	if n, err := s.FlushPrefix(); err != nil || n != 3 {
+		t.FailNow()
+	}
+	if !other.Exists("kept") {
+		t.FailNow()
+	}
+}
+
+func TestService_DeleteByPrefix_Progress(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	for i := 0; i < 5; i++ {
+		if err := s.Set("purge_"+strconv.Itoa(i), i, time.Minute); err != nil {
+			t.FailNow()
+		}
+	}
+	calls, lastDeleted := 0, 0
+	start := time.Now()
+	n, err := s.DeleteByPrefix("purge_", WithDeleteRateLimit(2), WithDeleteProgress(func(scanned, deleted int) {
+		calls++
+		lastDeleted = deleted
+	}))
+	if err != nil || n != 5 || lastDeleted != 5 || calls != 3 {
+		t.FailNow()
+	}
+	if time.Since(start) < 2*time.Second {
+		t.FailNow()
+	}
+}
+
+func TestService_DeleteByPrefix_BatchSize(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	for i := 0; i < 5; i++ {
+		if err := s.Set("batch_"+strconv.Itoa(i), i, time.Minute); err != nil {
+			t.FailNow()
+		}
+	}
+	batches := 0
+	n, err := s.DeleteByPrefix("batch_", WithDeleteBatchSize(2), WithDeleteProgress(func(scanned, deleted int) {
+		batches++
+	}))
+	if err != nil || n != 5 || batches != 3 {
+		t.FailNow()
+	}
+}
+
+func TestService_DeleteByPrefix_SingleConnection(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword, WithMaxActive(1, true))
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	for i := 0; i < 5; i++ {
+		if err := s.Set("single_"+strconv.Itoa(i), i, time.Minute); err != nil {
+			t.FailNow()
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	n, err := s.WithContext(ctx).DeleteByPrefix("single_", WithDeleteBatchSize(2))
+	if err != nil || n != 5 {
+		t.FailNow()
+	}
+}
+
+func TestService_Sample(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix+"_sample")
+	defer s.FlushPrefix()
+	for i := 0; i < 10; i++ {
+		if err := s.Set("key_"+strconv.Itoa(i), i, time.Minute); err != nil {
+			t.FailNow()
+		}
+	}
+	keys, err := s.Sample(5)
+	if err != nil || len(keys) != 5 {
+		t.FailNow()
+	}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if seen[key] || !s.Exists(key) {
+			t.FailNow()
+		}
+		seen[key] = true
+	}
+	if keys, err := s.Sample(20); err != nil || len(keys) != 10 {
+		t.FailNow()
+	}
+}
+
+func TestService_Sample_NonPositive(t *testing.T) {
+	s := NewService(&redis.Pool{Dial: dialUnresponsive}, testPrefix)
+	for _, n := range []int{0, -1} {
+		if keys, err := s.Sample(n); err != nil || keys == nil || len(keys) != 0 {
+			t.FailNow()
+		}
+	}
+}
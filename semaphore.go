@@ -0,0 +1,52 @@
+package goredis
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// ErrSemaphoreFull is returned by AcquireSemaphore when limit holders already hold it
+var ErrSemaphoreFull = errors.New("goredis: semaphore full")
+
+// SemaphoreToken is a slot held in a semaphore until Release or its ttl expires
+type SemaphoreToken struct {
+	s   *Service
+	key string
+	id  string
+}
+
+// AcquireSemaphore takes one of the limit slots of the semaphore at key for ttl,
+// failing with ErrSemaphoreFull when all are held. Holders that do not release
+// their slot within ttl, e.g. crashed workers, lose it, so ttl must exceed the
+// work done while holding it.
+func (s *Service) AcquireSemaphore(key string, limit int, ttl time.Duration) (*SemaphoreToken, error) {
+	id, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	token := &SemaphoreToken{s: s, key: key, id: id}
+	ok, err := s.store.AcquireSemaphore(s.cacheKey(key), token.id, limit, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrSemaphoreFull
+	}
+	return token, nil
+}
+
+// randomToken returns a random value identifying the holder of a lock or slot
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Release frees the slot for another holder
+func (t *SemaphoreToken) Release() error {
+	return t.s.store.ReleaseSemaphore(t.s.cacheKey(t.key), t.id)
+}
@@ -0,0 +1,38 @@
+package goredis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestService_AcquireSemaphore(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "reports"
+	defer s.Delete(key)
+	first, err := s.AcquireSemaphore(key, 2, time.Minute)
+	if err != nil {
+		t.FailNow()
+	}
+	if _, err := s.AcquireSemaphore(key, 2, 200*time.Millisecond); err != nil {
+		t.FailNow()
+	}
+	if _, err := s.AcquireSemaphore(key, 2, time.Minute); err != ErrSemaphoreFull {
+		t.FailNow()
+	}
+	time.Sleep(300 * time.Millisecond)
+	third, err := s.AcquireSemaphore(key, 2, time.Minute)
+	if err != nil {
+		t.FailNow()
+	}
+	if err := first.Release(); err != nil {
+		t.FailNow()
+	}
+	if err := third.Release(); err != nil {
+		t.FailNow()
+	}
+	if _, err := s.AcquireSemaphore(key, 1, time.Minute); err != nil {
+		t.FailNow()
+	}
+}
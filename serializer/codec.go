@@ -0,0 +1,58 @@
+package serializer
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// codec encodes and decodes values of one concrete type
+type codec struct {
+	encode func(value interface{}) ([]byte, error)
+	decode func(byt []byte) (interface{}, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[reflect.Type]codec{}
+)
+
+func init() {
+	RegisterCodec(reflect.TypeOf(time.Time{}), encodeTime, decodeTime)
+}
+
+// RegisterCodec makes Serialize and Deserialize use encode and decode for values
+// of type t instead of the general encoding. decode must return a value of type t.
+// time.Time is registered by default and stored as RFC3339 text.
+func RegisterCodec(t reflect.Type, encode func(value interface{}) ([]byte, error),
+	decode func(byt []byte) (interface{}, error)) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[t] = codec{encode: encode, decode: decode}
+	disableFastPath(t)
+}
+
+func lookupCodec(t reflect.Type) (codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[t]
+	return c, ok
+}
+
+func encodeTime(value interface{}) ([]byte, error) {
+	return []byte(value.(time.Time).Format(time.RFC3339Nano)), nil
+}
+
+// decodeTime also accepts the gob encoding used before the codec was registered
+func decodeTime(byt []byte) (interface{}, error) {
+	t, err := time.Parse(time.RFC3339Nano, string(byt))
+	if err == nil {
+		return t, nil
+	}
+	if gob.NewDecoder(bytes.NewReader(byt)).Decode(&t) == nil {
+		return t, nil
+	}
+	return nil, err
+}
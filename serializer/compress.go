@@ -0,0 +1,163 @@
+package serializer
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// Compressor compresses serialized values. ID identifies the codec in the header
+// of stored values, it must be unique and never change once data was written.
+type Compressor interface {
+	ID() byte
+	Compress(b []byte) ([]byte, error)
+	Decompress(b []byte) ([]byte, error)
+}
+
+// Built-in compressors, registered by default. Snappy writes the snappy block
+// format and Zstd standard zstd frames. Other codecs can be plugged in with
+// RegisterCompressor.
+var (
+	Gzip    Compressor = streamCompressor{id: 1, writer: newGzipWriter, reader: newGzipReader}
+	Zlib    Compressor = streamCompressor{id: 2, writer: newZlibWriter, reader: zlib.NewReader}
+	Deflate Compressor = streamCompressor{id: 3, writer: newFlateWriter, reader: newFlateReader}
+	Snappy  Compressor = snappyCompressor{}
+	Zstd    Compressor = zstdCompressor{}
+)
+
+// compressedMagic starts every compressed value, it is followed by the codec ID
+var compressedMagic = []byte("\x00grz")
+
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[byte]Compressor{}
+)
+
+func init() {
+	RegisterCompressor(Gzip)
+	RegisterCompressor(Zlib)
+	RegisterCompressor(Deflate)
+	RegisterCompressor(Snappy)
+	RegisterCompressor(Zstd)
+}
+
+// RegisterCompressor makes values written with c readable by every Compressed
+// serializer, whichever compressor it writes with
+func RegisterCompressor(c Compressor) {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+	compressors[c.ID()] = c
+}
+
+func lookupCompressor(id byte) (Compressor, bool) {
+	compressorsMu.RLock()
+	defer compressorsMu.RUnlock()
+	c, ok := compressors[id]
+	return c, ok
+}
+
+// Compressed wraps s to compress encoded values of at least minSize bytes with c.
+// A header records the codec, so values written with any registered compressor,
+// or uncompressed, are all read back correctly after switching codecs. Integers
+// are never compressed, so INCRBY still works on them.
+func Compressed(s Serializer, c Compressor, minSize int) Serializer {
+	RegisterCompressor(c)
+	return compressedSerializer{inner: s, compressor: c, minSize: minSize}
+}
+
+type compressedSerializer struct {
+	inner      Serializer
+	compressor Compressor
+	minSize    int
+}
+
+func (s compressedSerializer) Serialize(value interface{}) ([]byte, error) {
+	b, err := s.inner.Serialize(value)
+	if err != nil || len(b) < s.minSize || isInteger(value) {
+		return b, err
+	}
+	z, err := s.compressor.Compress(b)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(compressedMagic)+1+len(z))
+	out = append(out, compressedMagic...)
+	out = append(out, s.compressor.ID())
+	return append(out, z...), nil
+}
+
+func (s compressedSerializer) Deserialize(byt []byte, ptr interface{}) error {
+	if len(byt) > len(compressedMagic) && bytes.HasPrefix(byt, compressedMagic) {
+		id := byt[len(compressedMagic)]
+		c, ok := lookupCompressor(id)
+		if !ok {
+			return fmt.Errorf("serializer: unknown compressor %d", id)
+		}
+		b, err := c.Decompress(byt[len(compressedMagic)+1:])
+		if err != nil {
+			return err
+		}
+		byt = b
+	}
+	return s.inner.Deserialize(byt, ptr)
+}
+
+// streamCompressor adapts the compress/* stream readers and writers
+type streamCompressor struct {
+	id     byte
+	writer func(w io.Writer) (io.WriteCloser, error)
+	reader func(r io.Reader) (io.ReadCloser, error)
+}
+
+func (c streamCompressor) ID() byte {
+	return c.id
+}
+
+func (c streamCompressor) Compress(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := c.writer(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c streamCompressor) Decompress(b []byte) ([]byte, error) {
+	r, err := c.reader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+func newGzipWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func newGzipReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func newZlibWriter(w io.Writer) (io.WriteCloser, error) {
+	return zlib.NewWriter(w), nil
+}
+
+func newFlateWriter(w io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(w, flate.DefaultCompression)
+}
+
+func newFlateReader(r io.Reader) (io.ReadCloser, error) {
+	return flate.NewReader(r), nil
+}
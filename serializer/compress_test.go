@@ -0,0 +1,183 @@
+package serializer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/rand"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// compressInputs covers empty and tiny inputs, long matches, inputs spanning
+// several zstd blocks and incompressible data
+func compressInputs() [][]byte {
+	inputs := [][]byte{
+		{},
+		[]byte("a"),
+		[]byte("ab"),
+		[]byte(strings.Repeat("compressible ", 100)),
+		[]byte(strings.Repeat("The quick brown fox jumps over the lazy dog. ", 20)),
+		bytes.Repeat([]byte{0}, 300<<10),
+		bytes.Repeat([]byte("abcdefghij"), 30000),
+	}
+	rnd := rand.New(rand.NewSource(1))
+	random := make([]byte, 200<<10)
+	rnd.Read(random)
+	inputs = append(inputs, random[:4096], random)
+	// text with repeats at every distance, up to past the snappy window
+	var mixed []byte
+	for len(mixed) < 200<<10 {
+		if n := len(mixed); n > 64 && rnd.Intn(2) == 0 {
+			length := 4 + rnd.Intn(60)
+			start := rnd.Intn(n - length)
+			mixed = append(mixed, mixed[start:start+length]...)
+		} else {
+			mixed = append(mixed, "lorem ipsum dolor sit amet "[rnd.Intn(20):]...)
+		}
+	}
+	return append(inputs, mixed)
+}
+
+func testRoundTrip(t *testing.T, c Compressor, in []byte) {
+	b, err := c.Compress(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.Decompress(b)
+	if err != nil || !bytes.Equal(out, in) {
+		t.Fatalf("compressor %d, %d bytes: %v", c.ID(), len(in), err)
+	}
+}
+
+func TestSnappy_RoundTrip(t *testing.T) {
+	for _, in := range compressInputs() {
+		testRoundTrip(t, Snappy, in)
+	}
+}
+
+func TestZstd_RoundTrip(t *testing.T) {
+	for _, in := range compressInputs() {
+		testRoundTrip(t, Zstd, in)
+	}
+}
+
+func TestSnappy_Reference(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		// empty
+		{"\x00", ""},
+		// a literal "a" then a 9 byte copy at offset 1
+		{"\x0a\x00a\x15\x01", "aaaaaaaaaa"},
+		// a literal then a copy with a 2 byte offset
+		{"\x0c\x0cabcd\x1e\x04\x00", "abcdabcdabcd"},
+		// a literal then a copy with a 4 byte offset
+		{"\x06\x04xy\x0f\x02\x00\x00\x00", "xyxyxy"},
+		// a literal longer than 60 bytes, its length in an extra byte
+		{"\x46\xf0\x45" + strings.Repeat("z", 70), strings.Repeat("z", 70)},
+	} {
+		if out, err := Snappy.Decompress([]byte(tc.in)); err != nil || string(out) != tc.want {
+			t.Fatalf("%q: %q, %v", tc.in, out, err)
+		}
+	}
+	for _, corrupt := range []string{
+		"",
+		"\xff\xff\xff",
+		// a copy before any literal
+		"\x04\x01\x01",
+		// a literal longer than the input
+		"\x0a\x0cab",
+		// decoding to fewer bytes than announced
+		"\x0a\x00a",
+	} {
+		if _, err := Snappy.Decompress([]byte(corrupt)); err == nil {
+			t.Fatalf("%q decoded", corrupt)
+		}
+	}
+}
+
+// zstdFrames were written by the zstd command line tool
+var zstdFrames = []struct {
+	name, frame string
+	want        []byte
+}{
+	{"empty", "28b52ffd240001000099e9d851", nil},
+	{"raw block", "28b52ffd0458010200000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3ff01367db",
+		[]byte("\x00\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c\x0d\x0e\x0f\x10\x11\x12\x13\x14\x15\x16\x17\x18\x19\x1a\x1b\x1c\x1d\x1e\x1f !\"#$%&'()*+,-./0123456789:;<=>?")},
+	{"repeated byte", "28b52ffd0458450000102222010093001668c4c104", bytes.Repeat([]byte{0x22}, 64)},
+	{"rle block", "28b52ffd00584d00001000000100e32b8005", make([]byte, 1000)},
+	{"huffman literals", "28b52ffd04687d020052451111907d50fa134a77287dbebe3bfb3aff95038026cb7cbe95cb393cfb8d9fbb90abbab0e727ae9fb7328283f4c6f51ece8faa3ecf7da7f159e6f99143fc93782bcb4a3f4a040100a50a2b55063e694f13",
+		[]byte(strings.Repeat("The quick brown fox jumps over the lazy dog. ", 20) + "Pack my box with five dozen liquor jugs.")},
+	{"checksum", "28b52ffd0458a5000068636f6d707265737369626c65200100f8a0ce2f1225cc50", []byte(strings.Repeat("compressible ", 40))},
+	{"level 19", "28b52ffd0468a5000070636f6d707265737369626c6520630100e0994aeed837bf", []byte(strings.Repeat("compressible ", 4)[:51])},
+	{"several blocks", "28b52ffd0448940000506162636465666768696a0100f3ffcd0b124c000008630100fcff3910024d000008650100dc131d0801c558ecc3",
+		bytes.Repeat([]byte("abcdefghij"), 30000)},
+}
+
+func TestZstd_Reference(t *testing.T) {
+	for _, tc := range zstdFrames {
+		frame, err := hex.DecodeString(tc.frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out, err := Zstd.Decompress(frame); err != nil || !bytes.Equal(out, tc.want) {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		// a corrupt checksum or a truncated frame is rejected
+		if tc.name == "checksum" {
+			frame[len(frame)-1]++
+			if _, err := Zstd.Decompress(frame); err == nil {
+				t.Fatalf("%s: corrupt checksum decoded", tc.name)
+			}
+		}
+		if _, err := Zstd.Decompress(frame[:len(frame)-1]); err == nil && len(tc.want) > 0 {
+			t.Fatalf("%s: truncated frame decoded", tc.name)
+		}
+	}
+}
+
+// TestZstd_CommandLine checks that the zstd command line tool, when
+// installed, decodes the frames Zstd writes
+func TestZstd_CommandLine(t *testing.T) {
+	path, err := exec.LookPath("zstd")
+	if err != nil {
+		t.Skip("zstd command not found")
+	}
+	for _, in := range compressInputs() {
+		b, err := Zstd.Compress(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := exec.Command(path, "-d", "-c", "-q")
+		cmd.Stdin = bytes.NewReader(b)
+		out, err := cmd.Output()
+		if err != nil || !bytes.Equal(out, in) {
+			t.Fatalf("%d bytes: %v", len(in), err)
+		}
+	}
+}
+
+func fuzzCompressor(f *testing.F, c Compressor) {
+	for _, in := range compressInputs() {
+		if len(in) < 1<<10 {
+			f.Add(in)
+		}
+	}
+	f.Fuzz(func(t *testing.T, in []byte) {
+		testRoundTrip(t, c, in)
+		// arbitrary input must fail cleanly, never panic
+		c.Decompress(in)
+	})
+}
+
+func FuzzSnappy(f *testing.F) {
+	f.Add([]byte("\x0a\x00a\x15\x01"))
+	fuzzCompressor(f, Snappy)
+}
+
+func FuzzZstd(f *testing.F) {
+	for _, tc := range zstdFrames {
+		frame, _ := hex.DecodeString(tc.frame)
+		f.Add(frame)
+	}
+	fuzzCompressor(f, Zstd)
+}
@@ -0,0 +1,217 @@
+package serializer
+
+import (
+	"math"
+	"math/bits"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+)
+
+// The fast paths below encode the most common types without reflection. They
+// produce exactly the bytes of the general path: decimal text for integers and
+// the gob encoding of a single value for strings and floats, so data written
+// by either path reads back with the other.
+
+// gob type ids of the predeclared types, as written in the stream
+const (
+	gobFloat  = 4 << 1
+	gobString = 6 << 1
+)
+
+// fastPathTypes are the types handled by the fast paths. Registering a codec
+// for one of them disables the fast paths so the codec takes precedence.
+var fastPathTypes = map[reflect.Type]bool{
+	reflect.TypeOf(""):         true,
+	reflect.TypeOf(int(0)):     true,
+	reflect.TypeOf(int8(0)):    true,
+	reflect.TypeOf(int16(0)):   true,
+	reflect.TypeOf(int32(0)):   true,
+	reflect.TypeOf(int64(0)):   true,
+	reflect.TypeOf(uint(0)):    true,
+	reflect.TypeOf(uint8(0)):   true,
+	reflect.TypeOf(uint16(0)):  true,
+	reflect.TypeOf(uint32(0)):  true,
+	reflect.TypeOf(uint64(0)):  true,
+	reflect.TypeOf(float32(0)): true,
+	reflect.TypeOf(float64(0)): true,
+}
+
+var fastPathDisabled int32
+
+func disableFastPath(t reflect.Type) {
+	if fastPathTypes[t] {
+		atomic.StoreInt32(&fastPathDisabled, 1)
+	}
+}
+
+// serializeFast encodes value when it is of a fast path type
+func serializeFast(value interface{}) ([]byte, bool) {
+	if atomic.LoadInt32(&fastPathDisabled) != 0 {
+		return nil, false
+	}
+	switch v := value.(type) {
+	case string:
+		return gobValue(gobString, appendGobUint(nil, uint64(len(v))), v), true
+	case int:
+		return strconv.AppendInt(nil, int64(v), 10), true
+	case int8:
+		return strconv.AppendInt(nil, int64(v), 10), true
+	case int16:
+		return strconv.AppendInt(nil, int64(v), 10), true
+	case int32:
+		return strconv.AppendInt(nil, int64(v), 10), true
+	case int64:
+		return strconv.AppendInt(nil, v, 10), true
+	case uint:
+		return strconv.AppendUint(nil, uint64(v), 10), true
+	case uint8:
+		return strconv.AppendUint(nil, uint64(v), 10), true
+	case uint16:
+		return strconv.AppendUint(nil, uint64(v), 10), true
+	case uint32:
+		return strconv.AppendUint(nil, uint64(v), 10), true
+	case uint64:
+		return strconv.AppendUint(nil, v, 10), true
+	case float32:
+		return gobValue(gobFloat, appendGobUint(nil, bits.ReverseBytes64(math.Float64bits(float64(v)))), ""), true
+	case float64:
+		return gobValue(gobFloat, appendGobUint(nil, bits.ReverseBytes64(math.Float64bits(v))), ""), true
+	}
+	return nil, false
+}
+
+// deserializeFast decodes byt into ptr when it points to a fast path type. It
+// reports false when the general path must be used instead, including for input
+// it does not recognize, so errors are always those of the general path.
+func deserializeFast(byt []byte, ptr interface{}) (bool, error) {
+	if atomic.LoadInt32(&fastPathDisabled) != 0 {
+		return false, nil
+	}
+	switch p := ptr.(type) {
+	case *string:
+		body, ok := gobBody(byt, gobString)
+		if !ok {
+			return false, nil
+		}
+		n, body, ok := readGobUint(body)
+		if !ok || uint64(len(body)) != n {
+			return false, nil
+		}
+		*p = string(body)
+		return true, nil
+	case *float64:
+		f, ok := gobFloatValue(byt)
+		if ok {
+			*p = f
+		}
+		return ok, nil
+	case *float32:
+		f, ok := gobFloatValue(byt)
+		if !ok || math.Abs(f) > math.MaxFloat32 && !math.IsInf(f, 0) {
+			return false, nil
+		}
+		*p = float32(f)
+		return true, nil
+	case *int, *int8, *int16, *int32, *int64:
+		i, err := strconv.ParseInt(string(byt), 10, 64)
+		if err != nil {
+			return true, err
+		}
+		switch p := p.(type) {
+		case *int:
+			*p = int(i)
+		case *int8:
+			*p = int8(i)
+		case *int16:
+			*p = int16(i)
+		case *int32:
+			*p = int32(i)
+		case *int64:
+			*p = i
+		}
+		return true, nil
+	case *uint, *uint8, *uint16, *uint32, *uint64:
+		u, err := strconv.ParseUint(string(byt), 10, 64)
+		if err != nil {
+			return true, err
+		}
+		switch p := p.(type) {
+		case *uint:
+			*p = uint(u)
+		case *uint8:
+			*p = uint8(u)
+		case *uint16:
+			*p = uint16(u)
+		case *uint32:
+			*p = uint32(u)
+		case *uint64:
+			*p = u
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// gobValue builds the gob stream of a single value of the predeclared type typeID:
+// the message length, the type id, the singleton field delta and the value
+func gobValue(typeID byte, encoded []byte, data string) []byte {
+	n := 2 + len(encoded) + len(data)
+	b := appendGobUint(make([]byte, 0, n+9), uint64(n))
+	b = append(b, typeID, 0)
+	b = append(b, encoded...)
+	return append(b, data...)
+}
+
+// gobBody returns the value part of the gob stream of a single value of type typeID
+func gobBody(byt []byte, typeID byte) ([]byte, bool) {
+	n, body, ok := readGobUint(byt)
+	if !ok || uint64(len(body)) != n || len(body) < 2 || body[0] != typeID || body[1] != 0 {
+		return nil, false
+	}
+	return body[2:], true
+}
+
+func gobFloatValue(byt []byte) (float64, bool) {
+	body, ok := gobBody(byt, gobFloat)
+	if !ok {
+		return 0, false
+	}
+	u, rest, ok := readGobUint(body)
+	if !ok || len(rest) != 0 {
+		return 0, false
+	}
+	return math.Float64frombits(bits.ReverseBytes64(u)), true
+}
+
+// appendGobUint appends u in the gob unsigned integer encoding: values below 128
+// take one byte, larger ones their negated byte count followed by the big endian bytes
+func appendGobUint(b []byte, u uint64) []byte {
+	if u < 0x80 {
+		return append(b, byte(u))
+	}
+	n := (bits.Len64(u) + 7) / 8
+	b = append(b, byte(-n))
+	for i := n - 1; i >= 0; i-- {
+		b = append(b, byte(u>>(8*uint(i))))
+	}
+	return b
+}
+
+func readGobUint(b []byte) (uint64, []byte, bool) {
+	if len(b) == 0 {
+		return 0, nil, false
+	}
+	if b[0] < 0x80 {
+		return uint64(b[0]), b[1:], true
+	}
+	n := int(-int8(b[0]))
+	if n > 8 || len(b) < 1+n {
+		return 0, nil, false
+	}
+	var u uint64
+	for _, c := range b[1 : 1+n] {
+		u = u<<8 | uint64(c)
+	}
+	return u, b[1+n:], true
+}
@@ -16,6 +16,14 @@ func Serialize(value interface{}) ([]byte, error) {
 		return bytes2, nil
 	}
 
+	if b, ok := serializeFast(value); ok {
+		return b, nil
+	}
+
+	if c, ok := lookupCodec(reflect.TypeOf(value)); ok {
+		return c.encode(value)
+	}
+
 	switch v := reflect.ValueOf(value); v.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return []byte(strconv.FormatInt(v.Int(), 10)), nil
@@ -31,6 +39,26 @@ func Serialize(value interface{}) ([]byte, error) {
 	return b.Bytes(), nil
 }
 
+// isInteger reports whether value is of an integer kind, which Serialize writes
+// in decimal
+func isInteger(value interface{}) bool {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// IsNil reports whether value is nil or a nil pointer, which cannot be serialized
+func IsNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
 // Deserialize deserialices the passed []byte into a the passed ptr interface{}
 func Deserialize(byt []byte, ptr interface{}) (err error) {
 	if bytes2, ok := ptr.(*[]byte); ok {
@@ -38,7 +66,20 @@ func Deserialize(byt []byte, ptr interface{}) (err error) {
 		return nil
 	}
 
+	if ok, err := deserializeFast(byt, ptr); ok {
+		return err
+	}
+
 	if v := reflect.ValueOf(ptr); v.Kind() == reflect.Ptr {
+		if c, ok := lookupCodec(v.Type().Elem()); ok {
+			decoded, err := c.decode(byt)
+			if err != nil {
+				return err
+			}
+			v.Elem().Set(reflect.ValueOf(decoded))
+			return nil
+		}
+
 		switch p := v.Elem(); p.Kind() {
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 			var i int64
@@ -69,3 +110,43 @@ func Deserialize(byt []byte, ptr interface{}) (err error) {
 	}
 	return nil
 }
+
+// Serializer converts values to and from their stored representation
+type Serializer interface {
+	Serialize(value interface{}) ([]byte, error)
+	Deserialize(byt []byte, ptr interface{}) error
+}
+
+// Default is the Serializer backed by Serialize and Deserialize
+var Default Serializer = defaultSerializer{}
+
+// String stores strings as their raw bytes, without any encoding, so they can
+// be read by other clients. Other values are handled by Default.
+var String Serializer = stringSerializer{}
+
+type defaultSerializer struct{}
+
+func (defaultSerializer) Serialize(value interface{}) ([]byte, error) {
+	return Serialize(value)
+}
+
+func (defaultSerializer) Deserialize(byt []byte, ptr interface{}) error {
+	return Deserialize(byt, ptr)
+}
+
+type stringSerializer struct{}
+
+func (stringSerializer) Serialize(value interface{}) ([]byte, error) {
+	if s, ok := value.(string); ok {
+		return []byte(s), nil
+	}
+	return Serialize(value)
+}
+
+func (stringSerializer) Deserialize(byt []byte, ptr interface{}) error {
+	if s, ok := ptr.(*string); ok {
+		*s = string(byt)
+		return nil
+	}
+	return Deserialize(byt, ptr)
+}
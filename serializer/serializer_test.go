@@ -0,0 +1,110 @@
+package serializer
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math"
+	"strings"
+	"testing"
+)
+
+func gobEncode(t testing.TB, value interface{}) []byte {
+	var b bytes.Buffer
+	if err := gob.NewEncoder(&b).Encode(value); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// TestSerializeFastPath checks that the reflection-free fast paths write the
+// same bytes as the general gob path and read them back
+func TestSerializeFastPath(t *testing.T) {
+	for _, s := range []string{"", "hi", strings.Repeat("a", 200), strings.Repeat("b", 70000)} {
+		b, err := Serialize(s)
+		if err != nil || !bytes.Equal(b, gobEncode(t, s)) {
+			t.Fatalf("string of %d bytes: encoding differs from gob", len(s))
+		}
+		var v string
+		if err := Deserialize(b, &v); err != nil || v != s {
+			t.Fatalf("string of %d bytes: round-trip failed", len(s))
+		}
+	}
+	for _, f := range []float64{0, 1.5, -2.25, math.MaxFloat64, math.Inf(-1)} {
+		b, err := Serialize(f)
+		if err != nil || !bytes.Equal(b, gobEncode(t, f)) {
+			t.Fatalf("%v: encoding differs from gob", f)
+		}
+		var v float64
+		if err := Deserialize(b, &v); err != nil || v != f {
+			t.Fatalf("%v: round-trip failed", f)
+		}
+	}
+	b, err := Serialize(float32(0.5))
+	if err != nil || !bytes.Equal(b, gobEncode(t, float32(0.5))) {
+		t.FailNow()
+	}
+	var f32 float32
+	if err := Deserialize(gobEncode(t, math.MaxFloat64), &f32); err == nil {
+		t.FailNow()
+	}
+	b, err = Serialize(int64(-42))
+	if err != nil || string(b) != "-42" {
+		t.FailNow()
+	}
+	var i8 int8
+	if err := Deserialize([]byte("7"), &i8); err != nil || i8 != 7 {
+		t.FailNow()
+	}
+}
+
+type benchValue struct {
+	Name  string
+	Count int
+}
+
+func BenchmarkSerialize_String(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Serialize("a typical cached string value")
+	}
+}
+
+func BenchmarkSerialize_StringGob(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		gobEncode(b, "a typical cached string value")
+	}
+}
+
+func BenchmarkDeserialize_String(b *testing.B) {
+	data, _ := Serialize("a typical cached string value")
+	var v string
+	for i := 0; i < b.N; i++ {
+		Deserialize(data, &v)
+	}
+}
+
+func BenchmarkDeserialize_StringGob(b *testing.B) {
+	data := gobEncode(b, "a typical cached string value")
+	var v string
+	for i := 0; i < b.N; i++ {
+		gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	}
+}
+
+func BenchmarkSerialize_Int64(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Serialize(int64(i))
+	}
+}
+
+func BenchmarkDeserialize_Int64(b *testing.B) {
+	var v int64
+	for i := 0; i < b.N; i++ {
+		Deserialize([]byte("1234567"), &v)
+	}
+}
+
+func BenchmarkSerialize_Struct(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Serialize(benchValue{Name: "a", Count: i})
+	}
+}
@@ -0,0 +1,167 @@
+package serializer
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errSnappyCorrupt = errors.New("serializer: corrupt snappy input")
+
+const (
+	snappyTagLiteral = 0x00
+	snappyTagCopy1   = 0x01
+	snappyTagCopy2   = 0x02
+	snappyTagCopy4   = 0x03
+
+	snappyHashBits = 14
+	snappyMinMatch = 4
+)
+
+// snappyCompressor writes the snappy block format, without the framing format
+type snappyCompressor struct{}
+
+func (snappyCompressor) ID() byte {
+	return 4
+}
+
+// Compress encodes b as the uncompressed length followed by literals and
+// back-references, found by a greedy search over a hash table of 4-byte sequences
+func (snappyCompressor) Compress(b []byte) ([]byte, error) {
+	out := make([]byte, binary.MaxVarintLen64, len(b)/2+16)
+	out = out[:binary.PutUvarint(out, uint64(len(b)))]
+	var table [1 << snappyHashBits]int32
+	for i := range table {
+		table[i] = -1
+	}
+	lit := 0
+	for i := 0; i+snappyMinMatch <= len(b); {
+		h := snappyHash(binary.LittleEndian.Uint32(b[i:]))
+		cand := int(table[h])
+		table[h] = int32(i)
+		if cand < 0 || i-cand > 0xffff || binary.LittleEndian.Uint32(b[cand:]) != binary.LittleEndian.Uint32(b[i:]) {
+			i++
+			continue
+		}
+		n := snappyMinMatch
+		for i+n < len(b) && b[cand+n] == b[i+n] {
+			n++
+		}
+		out = snappyLiteral(out, b[lit:i])
+		out = snappyCopy(out, i-cand, n)
+		i += n
+		lit = i
+	}
+	return snappyLiteral(out, b[lit:]), nil
+}
+
+// Decompress decodes a snappy block
+func (snappyCompressor) Decompress(b []byte) ([]byte, error) {
+	size, n := binary.Uvarint(b)
+	if n <= 0 || size > uint64(len(b))*64+64 {
+		return nil, errSnappyCorrupt
+	}
+	out := make([]byte, 0, size)
+	for s := n; s < len(b); {
+		tag := b[s]
+		var length, offset int
+		switch tag & 0x03 {
+		case snappyTagLiteral:
+			length = int(tag >> 2)
+			s++
+			if length >= 60 {
+				extra := length - 59
+				if s+extra > len(b) {
+					return nil, errSnappyCorrupt
+				}
+				length = 0
+				for i := extra - 1; i >= 0; i-- {
+					length = length<<8 | int(b[s+i])
+				}
+				s += extra
+			}
+			length++
+			if length <= 0 || s+length > len(b) {
+				return nil, errSnappyCorrupt
+			}
+			out = append(out, b[s:s+length]...)
+			s += length
+			continue
+		case snappyTagCopy1:
+			if s+2 > len(b) {
+				return nil, errSnappyCorrupt
+			}
+			length = 4 + int(tag>>2)&0x07
+			offset = int(tag>>5)<<8 | int(b[s+1])
+			s += 2
+		case snappyTagCopy2:
+			if s+3 > len(b) {
+				return nil, errSnappyCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(b[s+1:]))
+			s += 3
+		case snappyTagCopy4:
+			if s+5 > len(b) {
+				return nil, errSnappyCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(b[s+1:]))
+			s += 5
+		}
+		if offset <= 0 || offset > len(out) {
+			return nil, errSnappyCorrupt
+		}
+		// copies may overlap their own output, e.g. to repeat a run
+		for start := len(out) - offset; length > 0; length-- {
+			out = append(out, out[start])
+			start++
+		}
+	}
+	if uint64(len(out)) != size {
+		return nil, errSnappyCorrupt
+	}
+	return out, nil
+}
+
+func snappyHash(u uint32) uint32 {
+	return (u * 0x1e35a7bd) >> (32 - snappyHashBits)
+}
+
+// snappyLiteral appends lit as a literal element
+func snappyLiteral(out, lit []byte) []byte {
+	if len(lit) == 0 {
+		return out
+	}
+	n := len(lit) - 1
+	switch {
+	case n < 60:
+		out = append(out, byte(n)<<2|snappyTagLiteral)
+	case n < 1<<8:
+		out = append(out, 60<<2|snappyTagLiteral, byte(n))
+	case n < 1<<16:
+		out = append(out, 61<<2|snappyTagLiteral, byte(n), byte(n>>8))
+	case n < 1<<24:
+		out = append(out, 62<<2|snappyTagLiteral, byte(n), byte(n>>8), byte(n>>16))
+	default:
+		out = append(out, 63<<2|snappyTagLiteral, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+	}
+	return append(out, lit...)
+}
+
+// snappyCopy appends a back-reference of length bytes at offset, split into
+// copy elements of at most 64 bytes
+func snappyCopy(out []byte, offset, length int) []byte {
+	for length >= 68 {
+		out = append(out, 63<<2|snappyTagCopy2, byte(offset), byte(offset>>8))
+		length -= 64
+	}
+	if length > 64 {
+		// leave at least 4 bytes so the remainder fits a copy element
+		out = append(out, 59<<2|snappyTagCopy2, byte(offset), byte(offset>>8))
+		length -= 60
+	}
+	if length >= 4 && length <= 11 && offset < 2048 {
+		return append(out, byte(offset>>8)<<5|byte(length-4)<<2|snappyTagCopy1, byte(offset))
+	}
+	return append(out, byte(length-1)<<2|snappyTagCopy2, byte(offset), byte(offset>>8))
+}
@@ -0,0 +1,1117 @@
+package serializer
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+var errZstdCorrupt = errors.New("serializer: corrupt zstd input")
+
+const (
+	zstdMagic         = 0xFD2FB528
+	zstdSkippableMask = 0xFFFFFFF0
+	zstdSkippableBase = 0x184D2A50
+	zstdMaxBlockSize  = 128 << 10
+
+	zstdBlockRaw        = 0
+	zstdBlockRLE        = 1
+	zstdBlockCompressed = 2
+
+	zstdHashBits = 15
+	zstdMinMatch = 4
+)
+
+// zstdCompressor writes Zstandard (RFC 8878) frames, readable by any zstd tool,
+// and reads frames written by other encoders, dictionaries excepted
+type zstdCompressor struct{}
+
+func (zstdCompressor) ID() byte {
+	return 5
+}
+
+// Compress writes b as a single-segment frame with a content checksum. Each
+// block holds raw literals and the LZ77 matches found by a greedy search,
+// coded with the predefined FSE tables, unless storing it raw is smaller.
+func (zstdCompressor) Compress(b []byte) ([]byte, error) {
+	out := make([]byte, 0, len(b)/2+32)
+	out = appendUint32(out, zstdMagic)
+	// Single_Segment with Content_Checksum, the FCS field sized to len(b)
+	switch size := uint64(len(b)); {
+	case size < 256:
+		out = append(out, 0x24, byte(size))
+	case size < 65536+256:
+		out = append(out, 0x64)
+		out = append(out, byte(size-256), byte((size-256)>>8))
+	case size <= 0xFFFFFFFF:
+		out = append(out, 0xA4)
+		out = appendUint32(out, uint32(size))
+	default:
+		out = append(out, 0xE4)
+		out = appendUint32(appendUint32(out, uint32(size)), uint32(size>>32))
+	}
+	enc := zstdEncoder{src: b}
+	for i := range enc.table {
+		enc.table[i] = -1
+	}
+	for start := 0; ; start += zstdMaxBlockSize {
+		end := start + zstdMaxBlockSize
+		last := end >= len(b)
+		if last {
+			end = len(b)
+		}
+		out = enc.block(out, start, end, last)
+		if last {
+			break
+		}
+	}
+	return appendUint32(out, uint32(xxh64(b, 0))), nil
+}
+
+func appendUint32(out []byte, v uint32) []byte {
+	return append(out, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+// Decompress decodes the concatenated frames of b, skipping skippable frames
+func (zstdCompressor) Decompress(b []byte) ([]byte, error) {
+	var out []byte
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return nil, errZstdCorrupt
+		}
+		magic := binary.LittleEndian.Uint32(b)
+		if magic&zstdSkippableMask == zstdSkippableBase {
+			if len(b) < 8 {
+				return nil, errZstdCorrupt
+			}
+			size := uint64(binary.LittleEndian.Uint32(b[4:]))
+			if uint64(len(b)-8) < size {
+				return nil, errZstdCorrupt
+			}
+			b = b[8+size:]
+			continue
+		}
+		if magic != zstdMagic {
+			return nil, errZstdCorrupt
+		}
+		var err error
+		if out, b, err = zstdDecodeFrame(out, b[4:]); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// zstdEncoder finds matches over the whole input, so blocks reference the
+// content of the blocks before them
+type zstdEncoder struct {
+	src   []byte
+	table [1 << zstdHashBits]int32
+}
+
+// zstdSequence is literalLength literals followed by a match
+type zstdSequence struct {
+	literalLength, matchLength, offset uint32
+}
+
+// block appends the block of src[start:end]
+func (e *zstdEncoder) block(out []byte, start, end int, last bool) []byte {
+	src := e.src
+	var seqs []zstdSequence
+	lit := start
+	for i := start; i+zstdMinMatch <= end; {
+		h := (binary.LittleEndian.Uint32(src[i:]) * 2654435761) >> (32 - zstdHashBits)
+		cand := int(e.table[h])
+		e.table[h] = int32(i)
+		if cand < 0 || binary.LittleEndian.Uint32(src[cand:]) != binary.LittleEndian.Uint32(src[i:]) {
+			i++
+			continue
+		}
+		n := zstdMinMatch
+		for i+n < end && src[cand+n] == src[i+n] {
+			n++
+		}
+		seqs = append(seqs, zstdSequence{literalLength: uint32(i - lit), matchLength: uint32(n), offset: uint32(i - cand)})
+		i += n
+		lit = i
+	}
+	header := uint32(0)
+	if last {
+		header = 1
+	}
+	if body := zstdCompressBlock(src[start:end], seqs, lit-start); len(body) < end-start {
+		header |= zstdBlockCompressed<<1 | uint32(len(body))<<3
+		out = append(out, byte(header), byte(header>>8), byte(header>>16))
+		return append(out, body...)
+	}
+	header |= zstdBlockRaw<<1 | uint32(end-start)<<3
+	out = append(out, byte(header), byte(header>>8), byte(header>>16))
+	return append(out, src[start:end]...)
+}
+
+// zstdCompressBlock returns the body of a compressed block: the literals, raw,
+// then the sequences coded with the predefined tables. trailing is where the
+// literals after the last match start.
+func zstdCompressBlock(src []byte, seqs []zstdSequence, trailing int) []byte {
+	literals := make([]byte, 0, len(src))
+	pos := 0
+	for _, seq := range seqs {
+		literals = append(literals, src[pos:pos+int(seq.literalLength)]...)
+		pos += int(seq.literalLength + seq.matchLength)
+	}
+	literals = append(literals, src[trailing:]...)
+
+	var out []byte
+	switch n := len(literals); {
+	case n < 32:
+		out = append(out, byte(n)<<3)
+	case n < 4096:
+		out = append(out, byte(n)<<4|1<<2, byte(n>>4))
+	default:
+		out = append(out, byte(n)<<4|3<<2, byte(n>>4), byte(n>>12))
+	}
+	out = append(out, literals...)
+
+	switch n := len(seqs); {
+	case n == 0:
+		return append(out, 0)
+	case n < 128:
+		out = append(out, byte(n))
+	case n < 0x7F00:
+		out = append(out, byte(n>>8)+128, byte(n))
+	default:
+		out = append(out, 255, byte(n-0x7F00), byte((n-0x7F00)>>8))
+	}
+	// Literals_Lengths, Offsets and Match_Lengths all use the predefined mode
+	out = append(out, 0)
+
+	codes := make([][3]uint8, len(seqs))
+	for i, seq := range seqs {
+		codes[i] = [3]uint8{zstdLiteralLengthCode(seq.literalLength), zstdOffsetCode(seq.offset + 3), zstdMatchLengthCode(seq.matchLength)}
+	}
+	ll, of, ml := zstdPredefinedLL.encoder(), zstdPredefinedOF.encoder(), zstdPredefinedML.encoder()
+	var w bitWriter
+	extra := func(seq zstdSequence, code [3]uint8) {
+		w.add(uint64(seq.literalLength-zstdLiteralLengths[code[0]].baseline), zstdLiteralLengths[code[0]].bits)
+		w.add(uint64(seq.matchLength-zstdMatchLengths[code[2]].baseline), zstdMatchLengths[code[2]].bits)
+		w.add(uint64(seq.offset+3-1<<code[1]), code[1])
+	}
+	n := len(seqs) - 1
+	llState, ofState, mlState := ll.init(codes[n][0]), of.init(codes[n][1]), ml.init(codes[n][2])
+	extra(seqs[n], codes[n])
+	for i := n - 1; i >= 0; i-- {
+		ofState = of.encode(&w, ofState, codes[i][1])
+		mlState = ml.encode(&w, mlState, codes[i][2])
+		llState = ll.encode(&w, llState, codes[i][0])
+		extra(seqs[i], codes[i])
+	}
+	ml.flush(&w, mlState)
+	of.flush(&w, ofState)
+	ll.flush(&w, llState)
+	return append(out, w.close()...)
+}
+
+// zstdDecodeFrame appends the content of the frame at the start of b, after
+// its magic number, to out and returns the input left after it
+func zstdDecodeFrame(out, b []byte) ([]byte, []byte, error) {
+	if len(b) < 1 {
+		return nil, nil, errZstdCorrupt
+	}
+	fhd := b[0]
+	b = b[1:]
+	singleSegment := fhd&0x20 != 0
+	checksum := fhd&0x04 != 0
+	if fhd&0x08 != 0 {
+		return nil, nil, errZstdCorrupt
+	}
+	skip := 0
+	if !singleSegment {
+		skip++ // Window_Descriptor, all content is kept in memory
+	}
+	dictSize := [4]int{0, 1, 2, 4}[fhd&0x03]
+	fcsSize := [4]int{0, 2, 4, 8}[fhd>>6]
+	if fcsSize == 0 && singleSegment {
+		fcsSize = 1
+	}
+	if len(b) < skip+dictSize+fcsSize {
+		return nil, nil, errZstdCorrupt
+	}
+	for _, c := range b[skip : skip+dictSize] {
+		if c != 0 {
+			return nil, nil, errors.New("serializer: zstd dictionaries are not supported")
+		}
+	}
+	b = b[skip+dictSize+fcsSize:]
+
+	d := zstdDecoder{repeats: [3]uint32{1, 4, 8}}
+	frameStart := len(out)
+	for last := false; !last; {
+		if len(b) < 3 {
+			return nil, nil, errZstdCorrupt
+		}
+		header := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+		b = b[3:]
+		last = header&1 != 0
+		size := int(header >> 3)
+		switch (header >> 1) & 0x03 {
+		case zstdBlockRaw:
+			if len(b) < size {
+				return nil, nil, errZstdCorrupt
+			}
+			out = append(out, b[:size]...)
+			b = b[size:]
+		case zstdBlockRLE:
+			if len(b) < 1 || size > zstdMaxBlockSize {
+				return nil, nil, errZstdCorrupt
+			}
+			for i := 0; i < size; i++ {
+				out = append(out, b[0])
+			}
+			b = b[1:]
+		case zstdBlockCompressed:
+			if len(b) < size || size > zstdMaxBlockSize {
+				return nil, nil, errZstdCorrupt
+			}
+			var err error
+			if out, err = d.block(out, frameStart, b[:size]); err != nil {
+				return nil, nil, err
+			}
+			b = b[size:]
+		default:
+			return nil, nil, errZstdCorrupt
+		}
+	}
+	if checksum {
+		if len(b) < 4 {
+			return nil, nil, errZstdCorrupt
+		}
+		if binary.LittleEndian.Uint32(b) != uint32(xxh64(out[frameStart:], 0)) {
+			return nil, nil, errors.New("serializer: zstd checksum mismatch")
+		}
+		b = b[4:]
+	}
+	return out, b, nil
+}
+
+// zstdDecoder holds the state carried from block to block within a frame
+type zstdDecoder struct {
+	repeats         [3]uint32
+	huffman         *huffmanTable
+	ll, of, ml      *fseTable
+	literalsScratch []byte
+}
+
+// block appends the content of a compressed block to out
+func (d *zstdDecoder) block(out []byte, frameStart int, b []byte) ([]byte, error) {
+	literals, n, err := d.literals(b)
+	if err != nil {
+		return nil, err
+	}
+	b = b[n:]
+	if len(b) < 1 {
+		return nil, errZstdCorrupt
+	}
+	nbSeq := int(b[0])
+	switch {
+	case nbSeq == 0:
+		return append(out, literals...), nil
+	case nbSeq < 128:
+		b = b[1:]
+	case nbSeq < 255:
+		if len(b) < 2 {
+			return nil, errZstdCorrupt
+		}
+		nbSeq = (nbSeq-128)<<8 + int(b[1])
+		b = b[2:]
+	default:
+		if len(b) < 3 {
+			return nil, errZstdCorrupt
+		}
+		nbSeq = int(b[1]) + int(b[2])<<8 + 0x7F00
+		b = b[3:]
+	}
+	if len(b) < 1 || b[0]&0x03 != 0 {
+		return nil, errZstdCorrupt
+	}
+	modes := b[0]
+	b = b[1:]
+	if d.ll, b, err = zstdSequenceTable(d.ll, modes>>6, b, zstdPredefinedLL, 35, 9); err != nil {
+		return nil, err
+	}
+	if d.of, b, err = zstdSequenceTable(d.of, modes>>4&0x03, b, zstdPredefinedOF, 31, 8); err != nil {
+		return nil, err
+	}
+	if d.ml, b, err = zstdSequenceTable(d.ml, modes>>2&0x03, b, zstdPredefinedML, 52, 9); err != nil {
+		return nil, err
+	}
+
+	r, err := newReverseBitReader(b)
+	if err != nil {
+		return nil, err
+	}
+	llState, ofState, mlState := r.read(d.ll.log), r.read(d.of.log), r.read(d.ml.log)
+	for i := 0; i < nbSeq; i++ {
+		ofCode := d.of.cells[ofState].symbol
+		mlCode := d.ml.cells[mlState].symbol
+		llCode := d.ll.cells[llState].symbol
+		if ofCode > 31 || int(mlCode) >= len(zstdMatchLengths) || int(llCode) >= len(zstdLiteralLengths) {
+			return nil, errZstdCorrupt
+		}
+		offsetValue := uint32(1)<<ofCode + uint32(r.read(ofCode))
+		matchLength := zstdMatchLengths[mlCode].baseline + uint32(r.read(zstdMatchLengths[mlCode].bits))
+		literalLength := zstdLiteralLengths[llCode].baseline + uint32(r.read(zstdLiteralLengths[llCode].bits))
+		if i < nbSeq-1 {
+			llState = d.ll.next(llState, r)
+			mlState = d.ml.next(mlState, r)
+			ofState = d.of.next(ofState, r)
+		}
+		if r.overflow() {
+			return nil, errZstdCorrupt
+		}
+
+		offset := d.offset(offsetValue, literalLength)
+		if uint64(literalLength) > uint64(len(literals)) {
+			return nil, errZstdCorrupt
+		}
+		out = append(out, literals[:literalLength]...)
+		literals = literals[literalLength:]
+		if offset == 0 || uint64(offset) > uint64(len(out)-frameStart) {
+			return nil, errZstdCorrupt
+		}
+		for start := len(out) - int(offset); matchLength > 0; matchLength-- {
+			out = append(out, out[start])
+			start++
+		}
+	}
+	if !r.done() {
+		return nil, errZstdCorrupt
+	}
+	return append(out, literals...), nil
+}
+
+// offset resolves an offset value into a distance, tracking the repeat offsets
+func (d *zstdDecoder) offset(value, literalLength uint32) uint32 {
+	rep := &d.repeats
+	if value > 3 {
+		offset := value - 3
+		rep[2], rep[1], rep[0] = rep[1], rep[0], offset
+		return offset
+	}
+	idx := value - 1
+	if literalLength == 0 {
+		idx++
+	}
+	var offset uint32
+	switch idx {
+	case 0:
+		return rep[0]
+	case 1:
+		offset = rep[1]
+		rep[1] = rep[0]
+	case 2:
+		offset = rep[2]
+		rep[2], rep[1] = rep[1], rep[0]
+	default:
+		offset = rep[0] - 1
+		rep[2], rep[1] = rep[1], rep[0]
+	}
+	rep[0] = offset
+	return offset
+}
+
+// literals decodes the literals section at the start of b and returns the
+// literals and the size of the section
+func (d *zstdDecoder) literals(b []byte) ([]byte, int, error) {
+	if len(b) < 1 {
+		return nil, 0, errZstdCorrupt
+	}
+	kind := b[0] & 0x03
+	sizeFormat := b[0] >> 2 & 0x03
+	if kind < 2 {
+		var size, n int
+		switch sizeFormat {
+		case 0, 2:
+			size, n = int(b[0]>>3), 1
+		case 1:
+			if len(b) < 2 {
+				return nil, 0, errZstdCorrupt
+			}
+			size, n = int(b[0]>>4)|int(b[1])<<4, 2
+		case 3:
+			if len(b) < 3 {
+				return nil, 0, errZstdCorrupt
+			}
+			size, n = int(b[0]>>4)|int(b[1])<<4|int(b[2])<<12, 3
+		}
+		if kind == 0 {
+			if len(b) < n+size {
+				return nil, 0, errZstdCorrupt
+			}
+			return b[n : n+size], n + size, nil
+		}
+		if len(b) < n+1 || size > zstdMaxBlockSize {
+			return nil, 0, errZstdCorrupt
+		}
+		lits := make([]byte, size)
+		for i := range lits {
+			lits[i] = b[n]
+		}
+		return lits, n + 1, nil
+	}
+
+	headerSize, sizeBits, streams := 3, 10, 4
+	switch sizeFormat {
+	case 0:
+		streams = 1
+	case 2:
+		headerSize, sizeBits = 4, 14
+	case 3:
+		headerSize, sizeBits = 5, 18
+	}
+	if len(b) < headerSize {
+		return nil, 0, errZstdCorrupt
+	}
+	var header uint64
+	for i := headerSize - 1; i >= 0; i-- {
+		header = header<<8 | uint64(b[i])
+	}
+	mask := uint64(1)<<sizeBits - 1
+	regenerated := int(header >> 4 & mask)
+	compressed := int(header >> (4 + sizeBits) & mask)
+	if len(b) < headerSize+compressed || regenerated > zstdMaxBlockSize {
+		return nil, 0, errZstdCorrupt
+	}
+	src := b[headerSize : headerSize+compressed]
+	if kind == 2 {
+		table, n, err := readHuffmanTable(src)
+		if err != nil {
+			return nil, 0, err
+		}
+		d.huffman = table
+		src = src[n:]
+	} else if d.huffman == nil {
+		return nil, 0, errZstdCorrupt
+	}
+	if cap(d.literalsScratch) < regenerated {
+		d.literalsScratch = make([]byte, regenerated)
+	}
+	lits := d.literalsScratch[:regenerated]
+	if streams == 1 {
+		if err := d.huffman.decode(lits, src); err != nil {
+			return nil, 0, err
+		}
+		return lits, headerSize + compressed, nil
+	}
+	if len(src) < 6 {
+		return nil, 0, errZstdCorrupt
+	}
+	sizes := [4]int{int(binary.LittleEndian.Uint16(src)), int(binary.LittleEndian.Uint16(src[2:])), int(binary.LittleEndian.Uint16(src[4:]))}
+	sizes[3] = len(src) - 6 - sizes[0] - sizes[1] - sizes[2]
+	if sizes[3] < 0 {
+		return nil, 0, errZstdCorrupt
+	}
+	src = src[6:]
+	segment := (regenerated + 3) / 4
+	for i, size := range sizes {
+		start := i * segment
+		end := start + segment
+		if i == 3 {
+			end = regenerated
+		}
+		if start > end {
+			return nil, 0, errZstdCorrupt
+		}
+		if err := d.huffman.decode(lits[start:end], src[:size]); err != nil {
+			return nil, 0, err
+		}
+		src = src[size:]
+	}
+	return lits, headerSize + compressed, nil
+}
+
+// zstdSequenceTable returns the FSE table of a sequence field for mode, read
+// from b when described there, and the input left after its description
+func zstdSequenceTable(prev *fseTable, mode byte, b []byte, predefined *fseTable, maxSymbol, maxLog int) (*fseTable, []byte, error) {
+	switch mode {
+	case 0:
+		return predefined, b, nil
+	case 1:
+		if len(b) < 1 || int(b[0]) > maxSymbol {
+			return nil, nil, errZstdCorrupt
+		}
+		return &fseTable{cells: []fseCell{{symbol: b[0]}}}, b[1:], nil
+	case 2:
+		table, n, err := readFSETable(b, maxSymbol, maxLog)
+		if err != nil {
+			return nil, nil, err
+		}
+		return table, b[n:], nil
+	}
+	if prev == nil {
+		return nil, nil, errZstdCorrupt
+	}
+	return prev, b, nil
+}
+
+// zstdCode is a length code: its baseline and number of extra bits
+type zstdCode struct {
+	baseline uint32
+	bits     uint8
+}
+
+var zstdLiteralLengths = [36]zstdCode{
+	{0, 0}, {1, 0}, {2, 0}, {3, 0}, {4, 0}, {5, 0}, {6, 0}, {7, 0},
+	{8, 0}, {9, 0}, {10, 0}, {11, 0}, {12, 0}, {13, 0}, {14, 0}, {15, 0},
+	{16, 1}, {18, 1}, {20, 1}, {22, 1}, {24, 2}, {28, 2}, {32, 3}, {40, 3},
+	{48, 4}, {64, 6}, {128, 7}, {256, 8}, {512, 9}, {1024, 10}, {2048, 11}, {4096, 12},
+	{8192, 13}, {16384, 14}, {32768, 15}, {65536, 16},
+}
+
+var zstdMatchLengths = [53]zstdCode{
+	{3, 0}, {4, 0}, {5, 0}, {6, 0}, {7, 0}, {8, 0}, {9, 0}, {10, 0},
+	{11, 0}, {12, 0}, {13, 0}, {14, 0}, {15, 0}, {16, 0}, {17, 0}, {18, 0},
+	{19, 0}, {20, 0}, {21, 0}, {22, 0}, {23, 0}, {24, 0}, {25, 0}, {26, 0},
+	{27, 0}, {28, 0}, {29, 0}, {30, 0}, {31, 0}, {32, 0}, {33, 0}, {34, 0},
+	{35, 1}, {37, 1}, {39, 1}, {41, 1}, {43, 2}, {47, 2}, {51, 3}, {59, 3},
+	{67, 4}, {83, 4}, {99, 5}, {131, 7}, {259, 8}, {515, 9}, {1027, 10}, {2051, 11},
+	{4099, 12}, {8195, 13}, {16387, 14}, {32771, 15}, {65539, 16},
+}
+
+var (
+	zstdPredefinedLL = mustFSETable([]int16{
+		4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1,
+		2, 2, 2, 2, 2, 2, 2, 2, 2, 3, 2, 1, 1, 1, 1, 1,
+		-1, -1, -1, -1,
+	}, 6)
+	zstdPredefinedML = mustFSETable([]int16{
+		1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1,
+		-1, -1, -1, -1, -1,
+	}, 6)
+	zstdPredefinedOF = mustFSETable([]int16{
+		1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1,
+	}, 5)
+)
+
+func zstdLiteralLengthCode(n uint32) uint8 {
+	if n < 16 {
+		return uint8(n)
+	}
+	return zstdCodeOf(zstdLiteralLengths[:], n)
+}
+
+func zstdMatchLengthCode(n uint32) uint8 {
+	if n < 35 {
+		return uint8(n - 3)
+	}
+	return zstdCodeOf(zstdMatchLengths[:], n)
+}
+
+// zstdCodeOf returns the last code of codes whose baseline is at most n
+func zstdCodeOf(codes []zstdCode, n uint32) uint8 {
+	code := len(codes) - 1
+	for codes[code].baseline > n {
+		code--
+	}
+	return uint8(code)
+}
+
+func zstdOffsetCode(value uint32) uint8 {
+	return uint8(bits.Len32(value) - 1)
+}
+
+// fseTable is a finite state entropy decoding table
+type fseTable struct {
+	log   uint8
+	cells []fseCell
+	norm  []int16
+}
+
+type fseCell struct {
+	symbol uint8
+	bits   uint8
+	base   uint16
+}
+
+// next returns the state following state, reading its bits from r
+func (t *fseTable) next(state uint64, r *reverseBitReader) uint64 {
+	cell := t.cells[state]
+	return uint64(cell.base) + r.read(cell.bits)
+}
+
+func mustFSETable(norm []int16, log uint8) *fseTable {
+	t, err := buildFSETable(norm, log)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// buildFSETable spreads the symbols of the normalized distribution norm over a
+// table of 1<<log states, -1 standing for a probability below 1/(1<<log)
+func buildFSETable(norm []int16, log uint8) (*fseTable, error) {
+	size := 1 << log
+	t := &fseTable{log: log, cells: make([]fseCell, size), norm: norm}
+	next := make([]int, len(norm))
+	high := size
+	for s, n := range norm {
+		if n == -1 {
+			high--
+			t.cells[high].symbol = uint8(s)
+			next[s] = 1
+		}
+	}
+	step := size>>1 + size>>3 + 3
+	pos := 0
+	for s, n := range norm {
+		if n <= 0 {
+			continue
+		}
+		next[s] = int(n)
+		for i := 0; i < int(n); i++ {
+			t.cells[pos].symbol = uint8(s)
+			pos = (pos + step) & (size - 1)
+			for pos >= high {
+				pos = (pos + step) & (size - 1)
+			}
+		}
+	}
+	if pos != 0 {
+		return nil, errZstdCorrupt
+	}
+	for i := range t.cells {
+		s := t.cells[i].symbol
+		state := next[s]
+		next[s]++
+		nb := int(log) - (bits.Len(uint(state)) - 1)
+		t.cells[i].bits = uint8(nb)
+		t.cells[i].base = uint16(state<<nb - size)
+	}
+	return t, nil
+}
+
+// readFSETable reads an FSE table description and returns the table and the
+// size of the description
+func readFSETable(b []byte, maxSymbol, maxLog int) (*fseTable, int, error) {
+	r := bitReader{b: b}
+	log := int(r.read(4)) + 5
+	if log > maxLog {
+		return nil, 0, errZstdCorrupt
+	}
+	remaining := 1 << log
+	var norm []int16
+	for remaining > 0 && len(norm) <= maxSymbol {
+		nb := bits.Len(uint(remaining + 1))
+		val := int(r.peek(nb))
+		lowMask := 1<<(nb-1) - 1
+		threshold := 1<<nb - 1 - (remaining + 1)
+		if val&lowMask < threshold {
+			val &= lowMask
+			r.skip(nb - 1)
+		} else {
+			if val > lowMask {
+				val -= threshold
+			}
+			r.skip(nb)
+		}
+		proba := int16(val - 1)
+		if proba < 0 {
+			remaining += int(proba)
+		} else {
+			remaining -= int(proba)
+		}
+		norm = append(norm, proba)
+		if proba == 0 {
+			for {
+				repeat := int(r.read(2))
+				for i := 0; i < repeat; i++ {
+					norm = append(norm, 0)
+				}
+				if repeat != 3 {
+					break
+				}
+			}
+		}
+	}
+	if remaining != 0 || len(norm) > maxSymbol+1 || r.overflow() {
+		return nil, 0, errZstdCorrupt
+	}
+	t, err := buildFSETable(norm, uint8(log))
+	if err != nil {
+		return nil, 0, err
+	}
+	return t, (r.pos + 7) / 8, nil
+}
+
+// fseEncoder codes symbols with the states of an fseTable
+type fseEncoder struct {
+	log    uint8
+	states []uint16
+	deltas []fseDelta
+}
+
+type fseDelta struct {
+	nbBits    uint32
+	findState int32
+}
+
+// encoder builds the encoding tables matching the decoding table t
+func (t *fseTable) encoder() *fseEncoder {
+	size := len(t.cells)
+	e := &fseEncoder{log: t.log, states: make([]uint16, size), deltas: make([]fseDelta, len(t.norm))}
+	cumul := make([]int, len(t.norm)+1)
+	for s, n := range t.norm {
+		if n == -1 {
+			n = 1
+		}
+		cumul[s+1] = cumul[s] + int(n)
+	}
+	for u, cell := range t.cells {
+		e.states[cumul[cell.symbol]] = uint16(size + u)
+		cumul[cell.symbol]++
+	}
+	total := 0
+	for s, n := range t.norm {
+		switch {
+		case n == 0:
+		case n == -1 || n == 1:
+			e.deltas[s] = fseDelta{nbBits: uint32(t.log)<<16 - uint32(size), findState: int32(total - 1)}
+			total++
+		default:
+			maxBitsOut := uint32(t.log) - uint32(bits.Len(uint(n-1))-1)
+			minStatePlus := uint32(n) << maxBitsOut
+			e.deltas[s] = fseDelta{nbBits: maxBitsOut<<16 - minStatePlus, findState: int32(total - int(n))}
+			total += int(n)
+		}
+	}
+	return e
+}
+
+// init returns the state the last coded symbol, the first decoded, starts in
+func (e *fseEncoder) init(symbol uint8) uint32 {
+	d := e.deltas[symbol]
+	nbBitsOut := (d.nbBits + 1<<15) >> 16
+	value := nbBitsOut<<16 - d.nbBits
+	return uint32(e.states[int32(value>>nbBitsOut)+d.findState])
+}
+
+// encode writes the bits leading from the state of symbol to state and
+// returns the state of symbol
+func (e *fseEncoder) encode(w *bitWriter, state uint32, symbol uint8) uint32 {
+	d := e.deltas[symbol]
+	nbBitsOut := (state + d.nbBits) >> 16
+	w.add(uint64(state), uint8(nbBitsOut))
+	return uint32(e.states[int32(state>>nbBitsOut)+d.findState])
+}
+
+// flush writes state for the decoder to start from
+func (e *fseEncoder) flush(w *bitWriter, state uint32) {
+	w.add(uint64(state), e.log)
+}
+
+// huffmanTable decodes Huffman coded literals
+type huffmanTable struct {
+	maxBits uint8
+	symbols []uint8
+	bits    []uint8
+}
+
+// readHuffmanTable reads a Huffman tree description and returns the table and
+// the size of the description
+func readHuffmanTable(b []byte) (*huffmanTable, int, error) {
+	if len(b) < 1 {
+		return nil, 0, errZstdCorrupt
+	}
+	var weights []uint8
+	n := int(b[0])
+	if n < 128 {
+		if len(b) < 1+n {
+			return nil, 0, errZstdCorrupt
+		}
+		var err error
+		if weights, err = readHuffmanWeights(b[1 : 1+n]); err != nil {
+			return nil, 0, err
+		}
+		n++
+	} else {
+		count := n - 127
+		size := (count + 1) / 2
+		if len(b) < 1+size {
+			return nil, 0, errZstdCorrupt
+		}
+		for i := 0; i < count; i++ {
+			c := b[1+i/2]
+			if i%2 == 0 {
+				weights = append(weights, c>>4)
+			} else {
+				weights = append(weights, c&0x0f)
+			}
+		}
+		n = 1 + size
+	}
+	if len(weights) > 255 {
+		return nil, 0, errZstdCorrupt
+	}
+	sum := 0
+	for _, w := range weights {
+		if w > 11 {
+			return nil, 0, errZstdCorrupt
+		}
+		if w > 0 {
+			sum += 1 << (w - 1)
+		}
+	}
+	if sum == 0 {
+		return nil, 0, errZstdCorrupt
+	}
+	maxBits := bits.Len(uint(sum))
+	left := 1<<maxBits - sum
+	if left&(left-1) != 0 || maxBits > 11 {
+		return nil, 0, errZstdCorrupt
+	}
+	weights = append(weights, uint8(bits.Len(uint(left))))
+
+	t := &huffmanTable{maxBits: uint8(maxBits), symbols: make([]uint8, 1<<maxBits), bits: make([]uint8, 1<<maxBits)}
+	var rankCount [13]int
+	for _, w := range weights {
+		if w > 0 {
+			rankCount[maxBits+1-int(w)]++
+		}
+	}
+	var rankIdx [13]int
+	for i := maxBits; i >= 1; i-- {
+		rankIdx[i-1] = rankIdx[i] + rankCount[i]<<(maxBits-i)
+	}
+	for s, w := range weights {
+		if w == 0 {
+			continue
+		}
+		nb := maxBits + 1 - int(w)
+		code := rankIdx[nb]
+		length := 1 << (maxBits - nb)
+		for i := code; i < code+length; i++ {
+			t.symbols[i] = uint8(s)
+			t.bits[i] = uint8(nb)
+		}
+		rankIdx[nb] += length
+	}
+	return t, n, nil
+}
+
+// readHuffmanWeights decodes FSE compressed Huffman weights, two interleaved
+// states sharing one table
+func readHuffmanWeights(b []byte) ([]uint8, error) {
+	table, n, err := readFSETable(b, 255, 6)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newReverseBitReader(b[n:])
+	if err != nil {
+		return nil, err
+	}
+	var weights []uint8
+	s1, s2 := r.read(table.log), r.read(table.log)
+	for len(weights) < 255 {
+		weights = append(weights, table.cells[s1].symbol)
+		s1 = table.next(s1, r)
+		if r.overflow() {
+			weights = append(weights, table.cells[s2].symbol)
+			return weights, nil
+		}
+		weights = append(weights, table.cells[s2].symbol)
+		s2 = table.next(s2, r)
+		if r.overflow() {
+			weights = append(weights, table.cells[s1].symbol)
+			return weights, nil
+		}
+	}
+	return nil, errZstdCorrupt
+}
+
+// decode fills out with the symbols of the Huffman coded stream src
+func (t *huffmanTable) decode(out, src []byte) error {
+	r, err := newReverseBitReader(src)
+	if err != nil {
+		return err
+	}
+	mask := uint64(1)<<t.maxBits - 1
+	state := r.read(t.maxBits)
+	for i := range out {
+		out[i] = t.symbols[state]
+		nb := t.bits[state]
+		state = (state<<nb | r.read(nb)) & mask
+	}
+	if r.pos != -int(t.maxBits) {
+		return errZstdCorrupt
+	}
+	return nil
+}
+
+// bitReader reads a little-endian bitstream from its start
+type bitReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *bitReader) peek(n int) uint64 {
+	var v uint64
+	for i := 0; i < n; i++ {
+		p := r.pos + i
+		if p/8 < len(r.b) && r.b[p/8]>>(p%8)&1 != 0 {
+			v |= 1 << i
+		}
+	}
+	return v
+}
+
+func (r *bitReader) skip(n int) {
+	r.pos += n
+}
+
+func (r *bitReader) read(n int) uint64 {
+	v := r.peek(n)
+	r.skip(n)
+	return v
+}
+
+func (r *bitReader) overflow() bool {
+	return r.pos > len(r.b)*8
+}
+
+// reverseBitReader reads a bitstream from its end, where the highest set bit
+// of the last byte marks the start
+type reverseBitReader struct {
+	b   []byte
+	pos int
+}
+
+func newReverseBitReader(b []byte) (*reverseBitReader, error) {
+	if len(b) == 0 || b[len(b)-1] == 0 {
+		return nil, errZstdCorrupt
+	}
+	return &reverseBitReader{b: b, pos: (len(b)-1)*8 + bits.Len8(b[len(b)-1]) - 1}, nil
+}
+
+// read returns the n bits below the current position, zeros past the start
+func (r *reverseBitReader) read(n uint8) uint64 {
+	r.pos -= int(n)
+	var v uint64
+	for i := 0; i < int(n); i++ {
+		p := r.pos + i
+		if p >= 0 && r.b[p/8]>>(p%8)&1 != 0 {
+			v |= 1 << i
+		}
+	}
+	return v
+}
+
+// overflow reports whether reads went past the start of the stream
+func (r *reverseBitReader) overflow() bool {
+	return r.pos < 0
+}
+
+// done reports whether the stream was consumed exactly
+func (r *reverseBitReader) done() bool {
+	return r.pos == 0
+}
+
+// bitWriter writes a little-endian bitstream, closed for a reverseBitReader
+type bitWriter struct {
+	out   []byte
+	acc   uint64
+	count uint8
+}
+
+// add writes the n low bits of v
+func (w *bitWriter) add(v uint64, n uint8) {
+	for n > 0 {
+		take := n
+		if free := 64 - w.count; take > free {
+			take = free
+		}
+		w.acc |= (v & (1<<take - 1)) << w.count
+		w.count += take
+		v >>= take
+		n -= take
+		for w.count >= 8 {
+			w.out = append(w.out, byte(w.acc))
+			w.acc >>= 8
+			w.count -= 8
+		}
+	}
+}
+
+// close marks the end of the stream and pads it to a byte
+func (w *bitWriter) close() []byte {
+	w.add(1, 1)
+	if w.count > 0 {
+		w.out = append(w.out, byte(w.acc))
+	}
+	return w.out
+}
+
+const (
+	xxhPrime1 uint64 = 11400714785074694791
+	xxhPrime2 uint64 = 14029467366897019727
+	xxhPrime3 uint64 = 1609587929392839161
+	xxhPrime4 uint64 = 9650029242287828579
+	xxhPrime5 uint64 = 2870177450012600261
+)
+
+// xxh64 is the XXH64 hash zstd checksums frame content with
+func xxh64(b []byte, seed uint64) uint64 {
+	n := uint64(len(b))
+	var h uint64
+	if len(b) >= 32 {
+		v1 := seed + xxhPrime1 + xxhPrime2
+		v2 := seed + xxhPrime2
+		v3 := seed
+		v4 := seed - xxhPrime1
+		for ; len(b) >= 32; b = b[32:] {
+			v1 = xxhRound(v1, binary.LittleEndian.Uint64(b))
+			v2 = xxhRound(v2, binary.LittleEndian.Uint64(b[8:]))
+			v3 = xxhRound(v3, binary.LittleEndian.Uint64(b[16:]))
+			v4 = xxhRound(v4, binary.LittleEndian.Uint64(b[24:]))
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxhMerge(h, v1)
+		h = xxhMerge(h, v2)
+		h = xxhMerge(h, v3)
+		h = xxhMerge(h, v4)
+	} else {
+		h = seed + xxhPrime5
+	}
+	h += n
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= xxhRound(0, binary.LittleEndian.Uint64(b))
+		h = bits.RotateLeft64(h, 27)*xxhPrime1 + xxhPrime4
+	}
+	if len(b) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(b)) * xxhPrime1
+		h = bits.RotateLeft64(h, 23)*xxhPrime2 + xxhPrime3
+		b = b[4:]
+	}
+	for _, c := range b {
+		h ^= uint64(c) * xxhPrime5
+		h = bits.RotateLeft64(h, 11) * xxhPrime1
+	}
+	h ^= h >> 33
+	h *= xxhPrime2
+	h ^= h >> 29
+	h *= xxhPrime3
+	h ^= h >> 32
+	return h
+}
+
+func xxhRound(acc, input uint64) uint64 {
+	acc += input * xxhPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxhPrime1
+}
+
+func xxhMerge(acc, v uint64) uint64 {
+	acc ^= xxhRound(0, v)
+	return acc*xxhPrime1 + xxhPrime4
+}
@@ -1,63 +1,601 @@
 package goredis
 
 import (
+	"context"
+	"fmt"
 	"github.com/gomodule/redigo/redis"
 	"github.com/owngoals/go-redis/redisstore"
+	"github.com/owngoals/go-redis/serializer"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
-func NewService(pool *redis.Pool, prefix string) *Service {
-	return &Service{
+func NewService(pool *redis.Pool, prefix string, opts ...Option) *Service {
+	return NewServiceWithStore(redisstore.NewRedisCacheWithPool(pool, redisstore.DEFAULT), prefix, opts...)
+}
+
+// NewServiceWithStore returns a Service on top of any Store, e.g. a MemoryStore
+// in tests. Redis specific operations return redisstore.ErrNotSupport unless
+// store is a RedisStore. It panics when prefix is "goredis" or starts with
+// "goredis:", the namespace of the keys the Service keeps for itself.
+func NewServiceWithStore(store redisstore.Store, prefix string, opts ...Option) *Service {
+	if prefix == reservedNamespace || strings.HasPrefix(prefix, reservedNamespace+":") {
+		panic(fmt.Sprintf("goredis: prefix %q is reserved", prefix))
+	}
+	s := &Service{
 		prefix: prefix,
-		store:  redisstore.NewRedisCacheWithPool(pool, redisstore.DEFAULT),
+		cache:  store,
+		flight: &flightGroup{},
+	}
+	if rs, ok := store.(*redisstore.RedisStore); ok {
+		s.pool = rs.Pool()
+		s.store = rs
+	} else {
+		s.store = redisstore.NewRedisCacheWithPool(nil, redisstore.DEFAULT)
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 type Service struct {
-	prefix string
-	store  *redisstore.RedisStore
+	pool         *redis.Pool
+	prefix       string
+	cache        redisstore.Store
+	store        *redisstore.RedisStore
+	keyHasher    func(string) string
+	hashTag      func(string) string
+	flight       *flightGroup
+	jitter       *ttlJitter
+	poolDB       int
+	nilPolicy    NilPolicy
+	retry        RetryPolicy
+	expiration   ExpirationPolicy
+	invalidation string
+	serializer   serializer.Serializer
+	serverClock  bool
+	replicas     *replicaSet
+	primaryRead  bool
+	writeThrough *writeThrough
+	encodings    []serializer.Format
+
+	requireExpiration bool
+	maxValueSize      int
+	missOnDecodeError bool
+	allowFlush        bool
+	counters          *CounterConfig
+	versioned         bool
 }
 
 func (s *Service) Get(key string, value interface{}) error {
-	return s.store.Get(s.cacheKey(key), value)
+	return s.GetWith(key, value)
 }
 
 func (s *Service) Set(key string, value interface{}, expire time.Duration) error {
-	return s.store.Set(s.cacheKey(key), value, expire)
+	if s.nilPolicy == NilDelete && serializer.IsNil(value) {
+		err := s.cache.Delete(s.cacheKey(key))
+		if err == redisstore.ErrCacheMiss {
+			err = nil
+		}
+		return s.mirrorDelete(s.written(err, key), key)
+	}
+	if err := s.checkExpiration(expire); err != nil {
+		return err
+	}
+	encoded, err := s.encode(value)
+	if err != nil {
+		return err
+	}
+	ttl := s.jittered(expire)
+	err = s.written(s.retry.do(func() error {
+		return s.write(key, encoded, ttl, "")
+	}), key)
+	return s.mirrorSet(err, key, value, expire)
 }
 
 func (s *Service) Add(key string, value interface{}, expire time.Duration) error {
-	return s.store.Add(s.cacheKey(key), value, expire)
+	if err := s.checkExpiration(expire); err != nil {
+		return err
+	}
+	encoded, err := s.encode(value)
+	if err != nil {
+		return err
+	}
+	err = s.written(s.write(key, encoded, s.jittered(expire), "NX"), key)
+	return s.mirrorSet(err, key, value, expire)
+}
+
+// AddMulti sets every item whose key does not exist yet and returns the keys it created
+func (s *Service) AddMulti(items map[string]interface{}, expires time.Duration) ([]string, error) {
+	if err := s.checkExpiration(expires); err != nil {
+		return nil, err
+	}
+	prefixed := make(map[string]interface{}, len(items))
+	keys := make(map[string]string, len(items))
+	for key, value := range items {
+		encoded, err := s.encode(value)
+		if err != nil {
+			return nil, err
+		}
+		prefixed[s.cacheKey(key)] = encoded
+		keys[s.cacheKey(key)] = key
+	}
+	set, err := s.store.AddMulti(prefixed, s.jittered(expires))
+	for i, key := range set {
+		set[i] = keys[key]
+		if err == nil {
+			err = s.setEncodings(set[i], items[set[i]], expires)
+		}
+	}
+	return set, err
 }
 
 func (s *Service) Replace(key string, data interface{}, expire time.Duration) error {
-	return s.store.Replace(s.cacheKey(key), data, expire)
+	if err := s.checkExpiration(expire); err != nil {
+		return err
+	}
+	encoded, err := s.encode(data)
+	if err != nil {
+		return err
+	}
+	err = s.written(s.write(key, encoded, s.jittered(expire), "XX"), key)
+	return s.mirrorSet(err, key, data, expire)
+}
+
+// GetDel reads key and deletes it atomically, so a one-time value can only be consumed once
+func (s *Service) GetDel(key string, value interface{}) error {
+	var b []byte
+	if err := s.store.GetDel(s.cacheKey(key), &b); err != nil {
+		return err
+	}
+	if err := s.deleteEncodings(key); err != nil {
+		return err
+	}
+	if err := s.deleteVersions(key); err != nil {
+		return err
+	}
+	return s.decode(s.codec(), key, s.unversion(b), value)
+}
+
+// SetRange patches the raw bytes of a value in place and returns its new length.
+// It bypasses the serializer, so it is only meaningful for values stored as []byte.
+func (s *Service) SetRange(key string, offset int, data []byte) (int, error) {
+	return s.store.SetRange(s.cacheKey(key), offset, data)
+}
+
+// Copy duplicates src into dst and reports whether it did, false when dst exists
+// and replace is not set. Returns redisstore.ErrCacheMiss when src does not exist.
+func (s *Service) Copy(src, dst string, replace bool) (bool, error) {
+	copied, err := s.store.Copy(s.cacheKey(src), s.cacheKey(dst), replace)
+	if copied {
+		s.written(nil, dst)
+		err = s.copyEncodings(src, dst, replace)
+	}
+	return copied, err
 }
 
 func (s *Service) Delete(key string) error {
-	return s.store.Delete(s.cacheKey(key))
+	return s.mirrorDelete(s.written(s.cache.Delete(s.cacheKey(key)), key), key)
+}
+
+// DeleteIgnoreMissing is Delete without the existence check, it never returns ErrCacheMiss
+func (s *Service) DeleteIgnoreMissing(key string) error {
+	return s.mirrorDelete(s.written(s.store.DeleteIgnoreMissing(s.cacheKey(key)), key), key)
+}
+
+// DeleteMulti removes all keys in pipelined DEL commands and returns the number actually removed
+func (s *Service) DeleteMulti(keys ...string) (int, error) {
+	n, err := s.store.DeleteMulti(s.cacheKeys(keys)...)
+	return n, s.mirrorDelete(s.written(err, keys...), keys...)
 }
 
+// Increment adds data to the counter at key. A missing counter fails with
+// redisstore.ErrCacheMiss by default, see WithCounters. Counters written by Set
+// must be stored in decimal, see WithSerializer.
 func (s *Service) Increment(key string, data uint64) (uint64, error) {
-	return s.store.Increment(s.cacheKey(key), data)
+	return s.counter(key, false, func() (uint64, error) {
+		return s.cache.Increment(s.cacheKey(key), data)
+	})
+}
+
+// IncrementMod atomically adds delta to the counter at key and wraps it to
+// 0..modulus-1, e.g. for rotating ids. A missing counter is created at 0 by
+// default, see WithCounters. modulus must not exceed 2^52.
+func (s *Service) IncrementMod(key string, delta, modulus uint64) (uint64, error) {
+	return s.store.IncrementMod(s.cacheKey(key), delta, modulus, s.autoCreate(true))
 }
 
+// IncrementMulti adds every delta to its counter in a single round-trip and
+// returns the new values. Missing counters are created from 0 by default, or
+// left out of the result when WithCounters disables AutoCreate.
+func (s *Service) IncrementMulti(deltas map[string]int64) (map[string]int64, error) {
+	prefixed := make(map[string]int64, len(deltas))
+	keys := make(map[string]string, len(deltas))
+	for key, delta := range deltas {
+		prefixed[s.cacheKey(key)] = delta
+		keys[s.cacheKey(key)] = key
+	}
+	values, err := s.store.IncrementMulti(prefixed, s.autoCreate(true))
+	result := make(map[string]int64, len(values))
+	for key, n := range values {
+		result[keys[key]] = n
+	}
+	return result, err
+}
+
+// Decrement subtracts data from the counter at key, stopping at 0. A missing
+// counter fails with redisstore.ErrCacheMiss by default, see WithCounters.
 func (s *Service) Decrement(key string, data uint64) (uint64, error) {
-	return s.store.Decrement(s.cacheKey(key), data)
+	return s.counter(key, false, func() (uint64, error) {
+		return s.cache.Decrement(s.cacheKey(key), data)
+	})
 }
 
+// counter runs op on the counter at key, creating it at 0 and running op again
+// when it is missing and counters are auto-created
+func (s *Service) counter(key string, autoCreate bool, op func() (uint64, error)) (uint64, error) {
+	n, err := op()
+	if err != redisstore.ErrCacheMiss || !s.autoCreate(autoCreate) {
+		return n, err
+	}
+	if err := s.cache.Add(s.cacheKey(key), 0, redisstore.DEFAULT); err != nil && err != redisstore.ErrNotStored {
+		return 0, err
+	}
+	return op()
+}
+
+// autoCreate resolves whether a counter operation creates missing counters,
+// def being the default of the operation
+func (s *Service) autoCreate(def bool) bool {
+	if s.counters == nil {
+		return def
+	}
+	return s.counters.AutoCreate
+}
+
+// Flush empties the whole database, including keys of other prefixes.
+//
+// Deprecated: use FlushPrefix, or FlushAll when wiping the database is intended.
 func (s *Service) Flush() error {
-	return s.store.Flush()
+	return s.FlushAll()
+}
+
+// FlushAll empties the whole database the Service uses, whatever the prefix.
+// It fails with redisstore.ErrNotSupport unless the Service was created with AllowFlush.
+func (s *Service) FlushAll() error {
+	if !s.allowFlush {
+		return redisstore.ErrNotSupport
+	}
+	return s.cache.Flush()
 }
 
 func (s *Service) Exists(key string) bool {
-	return s.store.Exists(s.cacheKey(key))
+	return s.readCache().Exists(s.cacheKey(key))
+}
+
+// TTL returns the remaining time to live of key, redisstore.FOREVER when it never expires
+func (s *Service) TTL(key string) (ttl time.Duration, err error) {
+	store := s.readStore()
+	err = s.retry.do(func() error {
+		ttl, err = store.TTL(s.cacheKey(key))
+		return err
+	})
+	return ttl, err
+}
+
+// ExistsMulti reports the presence of every key in a single round-trip
+func (s *Service) ExistsMulti(keys ...string) (map[string]bool, error) {
+	var found []bool
+	err := s.retry.do(func() (err error) {
+		found, err = s.store.ExistsMulti(s.cacheKeys(keys)...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]bool, len(keys))
+	for i, key := range keys {
+		m[key] = found[i]
+	}
+	return m, nil
 }
 
 func (s *Service) SetExpire(key string, expires time.Duration) bool {
-	return s.store.SetExpire(s.cacheKey(key), expires)
+	return s.cache.SetExpire(s.cacheKey(key), expires)
+}
+
+// ExpireWithFlag updates the expiration of key only when flag's condition holds,
+// e.g. redisstore.ExpireGT to extend without ever shortening. Requires Redis 7.
+func (s *Service) ExpireWithFlag(key string, expires time.Duration, flag redisstore.ExpireFlag) (bool, error) {
+	return s.store.ExpireWithFlag(s.cacheKey(key), expires, flag)
+}
+
+// ExpireAt sets key to expire at the absolute time at and reports whether it
+// exists. With WithServerClock, at is first shifted by the skew between the
+// local clock and the server clock.
+func (s *Service) ExpireAt(key string, at time.Time) (bool, error) {
+	if s.serverClock {
+		now, err := s.store.ServerTime()
+		if err != nil {
+			return false, err
+		}
+		at = at.Add(now.Sub(time.Now()))
+	}
+	return s.store.ExpireAt(s.cacheKey(key), at)
+}
+
+// ServerTime returns the time of the server clock, immune to local clock skew
+func (s *Service) ServerTime() (time.Time, error) {
+	return s.store.ServerTime()
+}
+
+// GetAndTouch reads key and resets its expiration in one round-trip, for sliding expiration
+func (s *Service) GetAndTouch(key string, value interface{}, expires time.Duration) error {
+	var b []byte
+	if err := s.store.GetAndTouch(s.cacheKey(key), &b, expires); err != nil {
+		return err
+	}
+	return s.decode(s.codec(), key, s.unversion(b), value)
+}
+
+func (s *Service) Touch(key string, expires time.Duration) bool {
+	return s.store.Touch(s.cacheKey(key), expires)
+}
+
+// Pool returns the underlying pool for commands the Service does not wrap,
+// nil when the Service is not backed by Redis.
+// Raw commands bypass prefixing and serialization, use Key to build keys.
+// Connections taken from the pool must be closed to return them to it.
+func (s *Service) Pool() *redis.Pool {
+	return s.pool
+}
+
+// Drain stops the Service for a graceful shutdown: new Redis commands fail with
+// redisstore.ErrClosed, commands in flight are waited for until ctx is done, then
+// the pool is closed.
+func (s *Service) Drain(ctx context.Context) error {
+	if err := s.store.Drain(ctx); err != nil {
+		return err
+	}
+	if s.replicas != nil {
+		for _, p := range s.replicas.pools {
+			p.Close()
+		}
+	}
+	return nil
+}
+
+// PoolStats returns the connection counts and wait statistics of the underlying pool
+func (s *Service) PoolStats() redis.PoolStats {
+	if s.pool == nil {
+		return redis.PoolStats{}
+	}
+	return s.pool.Stats()
+}
+
+// Warmup opens n connections and PINGs them so they are idle in the pool before
+// traffic arrives. Connections beyond the pool MaxIdle are closed again. Returns
+// how many connections were warmed, along with the first error encountered.
+func (s *Service) Warmup(n int) (int, error) {
+	if s.pool == nil {
+		return 0, redisstore.ErrNotSupport
+	}
+	conns := make([]redis.Conn, 0, n)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	var firstErr error
+	for i := 0; i < n; i++ {
+		conn := s.pool.Get()
+		if _, err := conn.Do("PING"); err != nil {
+			conn.Close()
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		conns = append(conns, conn)
+	}
+	return len(conns), firstErr
+}
+
+// WithContext returns a shallow copy of the Service whose operations borrow
+// connections with ctx and fail with ctx.Err() once it is done. Reads are bounded
+// by the ctx deadline. The original Service keeps running without a context.
+func (s *Service) WithContext(ctx context.Context) *Service {
+	return s.withStore(s.store.WithContext(ctx))
+}
+
+// WithDB returns a copy of the Service whose operations run against database db
+// of the same pool. Borrowed connections SELECT db and are switched back to the
+// pool database (see WithPoolDB) before being returned, so the shared pool is
+// never left pointing at another database.
+func (s *Service) WithDB(db int) *Service {
+	return s.withStore(s.store.WithDB(db, s.poolDB))
+}
+
+// Primary returns a copy of the Service reading from the primary even when
+// WithReplicas is set, for reads that must observe the Service's own writes
+func (s *Service) Primary() *Service {
+	cp := *s
+	cp.primaryRead = true
+	return &cp
+}
+
+// withStore returns a shallow copy of the Service running on rs
+func (s *Service) withStore(rs *redisstore.RedisStore) *Service {
+	cp := *s
+	cp.setStore(rs)
+	return &cp
+}
+
+// setStore replaces the RedisStore, and the cache too when it is Redis backed
+func (s *Service) setStore(rs *redisstore.RedisStore) {
+	if _, ok := s.cache.(*redisstore.RedisStore); ok {
+		s.cache = rs
+	}
+	s.store = rs
+}
+
+// Key returns the key as stored in Redis, with the prefix (and key hasher) applied
+func (s *Service) Key(key string) string {
+	return s.cacheKey(key)
 }
 
 func (s *Service) cacheKey(key string) string {
-	return s.prefix + ":" + key
+	tag := ""
+	if s.hashTag != nil {
+		if t := s.hashTag(key); t != "" {
+			tag = "{" + t + "}:"
+		}
+	}
+	if s.keyHasher != nil {
+		key = s.keyHasher(key)
+	}
+	return s.prefix + ":" + tag + key
+}
+
+func (s *Service) cacheKeys(keys []string) []string {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = s.cacheKey(key)
+	}
+	return prefixed
+}
+
+// reservedNamespace starts the keys returned by reservedKey. NewServiceWithStore
+// rejects prefixes that would put user keys in it.
+const reservedNamespace = "goredis"
+
+// reservedKey returns the key holding the kind companion of key, e.g. its
+// version. It starts with "goredis:" rather than the prefix, which cannot be
+// "goredis" or start with "goredis:", so it is never a user key, nor returned
+// by Scan or Sample or removed by FlushPrefix. It carries the hash tag of key,
+// so it maps to the same Redis Cluster slot.
+func (s *Service) reservedKey(kind, key string) string {
+	cacheKey := s.cacheKey(key)
+	if !hasHashTag(cacheKey) {
+		cacheKey = "{" + cacheKey + "}"
+	}
+	return reservedNamespace + ":" + kind + ":" + cacheKey
+}
+
+// hasHashTag reports whether Redis Cluster hashes key by a {tag} in it
+func hasHashTag(key string) bool {
+	i := strings.IndexByte(key, '{')
+	return i >= 0 && strings.IndexByte(key[i+1:], '}') > 0
+}
+
+// codec returns the Serializer configured with WithSerializer or WithCompressor,
+// or the default one
+func (s *Service) codec() serializer.Serializer {
+	if s.serializer != nil {
+		return s.serializer
+	}
+	return serializer.Default
+}
+
+// encode serializes value with the configured Serializer, if any or when
+// MaxValueSize must be enforced, before it is handed to the store. Nil values
+// are left for the store to reject.
+func (s *Service) encode(value interface{}) (interface{}, error) {
+	if (s.serializer == nil && s.maxValueSize <= 0) || serializer.IsNil(value) {
+		return value, nil
+	}
+	b, err := s.codec().Serialize(value)
+	if err != nil {
+		return nil, err
+	}
+	return b, s.checkSize(b)
+}
+
+// encodeAll is encode applied to each of values
+func (s *Service) encodeAll(values []interface{}) ([]interface{}, error) {
+	encoded := make([]interface{}, len(values))
+	for i, value := range values {
+		var err error
+		if encoded[i], err = s.encode(value); err != nil {
+			return nil, err
+		}
+	}
+	return encoded, nil
+}
+
+// decodeFrom decodes into ptrValue the stored form of the value of key that
+// read fills in
+func (s *Service) decodeFrom(key string, ptrValue interface{}, read func(b *[]byte) error) error {
+	var b []byte
+	if err := read(&b); err != nil {
+		return err
+	}
+	return s.decode(s.codec(), key, b, ptrValue)
+}
+
+// checkSize enforces MaxValueSize on a serialized value
+func (s *Service) checkSize(b []byte) error {
+	if s.maxValueSize > 0 && len(b) > s.maxValueSize {
+		return ErrValueTooLarge
+	}
+	return nil
+}
+
+// decode deserializes the stored value b of key into ptrValue with ser, reporting
+// failures as a DecodeError, or as a miss with MissOnDecodeError
+func (s *Service) decode(ser serializer.Serializer, key string, b []byte, ptrValue interface{}) error {
+	err := ser.Deserialize(b, ptrValue)
+	if err == nil {
+		return nil
+	}
+	if s.missOnDecodeError {
+		return redisstore.ErrCacheMiss
+	}
+	return &DecodeError{Key: key, Type: fmt.Sprintf("%T", ptrValue), Err: err}
+}
+
+// replicaSet round-robins reads over the replica pools
+type replicaSet struct {
+	pools []*redis.Pool
+	next  uint32
+}
+
+// readStore returns the RedisStore to read from: the next replica when
+// WithReplicas is set, the primary otherwise
+func (s *Service) readStore() *redisstore.RedisStore {
+	if s.replicas == nil || len(s.replicas.pools) == 0 || s.primaryRead {
+		return s.store
+	}
+	i := atomic.AddUint32(&s.replicas.next, 1)
+	return s.store.WithPool(s.replicas.pools[int(i%uint32(len(s.replicas.pools)))])
+}
+
+// readCache is readStore for the operations served by the cache Store
+func (s *Service) readCache() redisstore.Store {
+	if rs := s.readStore(); rs != s.store {
+		return rs
+	}
+	return s.cache
+}
+
+// checkExpiration enforces RequireExpiration on a write passing expires
+func (s *Service) checkExpiration(expires time.Duration) error {
+	if !s.requireExpiration || expires != redisstore.DEFAULT {
+		return nil
+	}
+	if s.defaultExpiration() != redisstore.DEFAULT {
+		return nil
+	}
+	return ErrNoExpiration
+}
+
+// defaultExpiration returns the expiration the store applies to writes passing
+// DEFAULT, DEFAULT itself when it has none or does not say
+func (s *Service) defaultExpiration() time.Duration {
+	if d, ok := s.cache.(interface{ DefaultExpiration() time.Duration }); ok {
+		return d.DefaultExpiration()
+	}
+	return redisstore.DEFAULT
 }
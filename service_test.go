@@ -1,11 +1,19 @@
 package goredis
 
 import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+	"github.com/owngoals/go-redis/serializer"
 )
 
-const testPrefix = "goredis"
+const testPrefix = "goredis_test"
 
 func TestService_Get(t *testing.T) {
 	p := CreatePool(testHost, testPort, testDb, testPassword)
@@ -28,3 +36,775 @@ func TestService_Get(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestService_AddMulti(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.Delete("seed_a")
+	defer s.Delete("seed_b")
+	if err := s.Set("seed_a", "old", 1*time.Minute); err != nil {
+		t.FailNow()
+	}
+	set, err := s.AddMulti(map[string]interface{}{"seed_a": "new", "seed_b": "new"}, 1*time.Minute)
+	if err != nil || len(set) != 1 || set[0] != "seed_b" {
+		t.FailNow()
+	}
+	var v string
+	if err := s.Get("seed_a", &v); err != nil || v != "old" {
+		t.FailNow()
+	}
+	defer s.Delete("seed_c")
+	if _, err := s.AddMulti(map[string]interface{}{"seed_c": "new", "seed_d": make(chan int)}, 1*time.Minute); err == nil {
+		t.FailNow()
+	}
+	if s.Exists("seed_c") {
+		t.FailNow()
+	}
+}
+
+func TestService_WithKeyHasher(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix, WithKeyHasher(SHA256KeyHasher))
+	key := "https://example.com/search?q=a+very+long+query"
+	defer s.Delete(key)
+	if err := s.Set(key, "hello", 1*time.Minute); err != nil {
+		t.FailNow()
+	}
+	var v string
+	if err := s.Get(key, &v); err != nil || v != "hello" {
+		t.FailNow()
+	}
+	if !NewService(p, testPrefix).Exists(SHA256KeyHasher(key)) {
+		t.FailNow()
+	}
+}
+
+func TestService_WithHashTag(t *testing.T) {
+	s := NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), testPrefix,
+		WithHashTag(func(key string) string {
+			return strings.SplitN(key, ":", 2)[0]
+		}))
+	key := "tenant1:user:5"
+	if s.Key(key) != testPrefix+":{tenant1}:"+key {
+		t.FailNow()
+	}
+	if s.stripKey(s.Key(key)) != key {
+		t.FailNow()
+	}
+}
+
+func TestNewService_ReservedPrefix(t *testing.T) {
+	for _, prefix := range []string{"goredis", "goredis:version"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.FailNow()
+				}
+			}()
+			NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), prefix)
+		}()
+	}
+	s := NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), "goredis_app")
+	if s.VersionKey("x") != "goredis:version:{goredis_app:x}" || strings.HasPrefix(s.VersionKey("x"), "goredis_app:") {
+		t.FailNow()
+	}
+}
+
+func TestService_GetAndTouch(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "session"
+	defer s.Delete(key)
+	if err := s.Set(key, "hello", 2*time.Second); err != nil {
+		t.FailNow()
+	}
+	var v string
+	if err := s.GetAndTouch(key, &v, 1*time.Minute); err != nil || v != "hello" {
+		t.FailNow()
+	}
+	time.Sleep(3 * time.Second)
+	if !s.Touch(key, 1*time.Minute) {
+		t.FailNow()
+	}
+	if err := s.GetAndTouch("missing", &v, 1*time.Minute); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+}
+
+func TestService_DeleteMulti(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	for _, key := range []string{"a", "b"} {
+		if err := s.Set(key, key, 1*time.Minute); err != nil {
+			t.FailNow()
+		}
+	}
+	if n, err := s.DeleteMulti("a", "b", "c"); err != nil || n != 2 {
+		t.FailNow()
+	}
+}
+
+func TestService_PoolStats(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	if stats := s.PoolStats(); stats.ActiveCount != 0 || stats.IdleCount != 0 {
+		t.FailNow()
+	}
+	conn := p.Get()
+	if _, err := conn.Do("PING"); err != nil {
+		t.FailNow()
+	}
+	if stats := s.PoolStats(); stats.ActiveCount != 1 || stats.IdleCount != 0 {
+		t.FailNow()
+	}
+	conn.Close()
+	if stats := s.PoolStats(); stats.ActiveCount != 1 || stats.IdleCount != 1 {
+		t.FailNow()
+	}
+	// the idle connection is reused rather than a second one dialed
+	if s.Exists("missing") {
+		t.FailNow()
+	}
+	if stats := s.PoolStats(); stats.ActiveCount != 1 || stats.IdleCount != 1 {
+		t.FailNow()
+	}
+}
+
+func TestService_MemoryStore(t *testing.T) {
+	store := redisstore.NewMemoryStore(redisstore.DEFAULT, time.Second)
+	defer store.Close()
+	s := NewServiceWithStore(store, testPrefix)
+	if err := s.Set("username", "hello", 1*time.Minute); err != nil {
+		t.FailNow()
+	}
+	var v string
+	if err := s.Get("username", &v); err != nil || v != "hello" {
+		t.FailNow()
+	}
+	if err := s.Add("username", "other", 1*time.Minute); err != redisstore.ErrNotStored {
+		t.FailNow()
+	}
+	if err := s.Set("counter", 5, 1*time.Minute); err != nil {
+		t.FailNow()
+	}
+	if n, err := s.Decrement("counter", 10); err != nil || n != 0 {
+		t.FailNow()
+	}
+	if !s.SetExpire("username", 0) || s.Exists("username") {
+		t.FailNow()
+	}
+	if _, err := s.LLen("list"); err != redisstore.ErrNotSupport {
+		t.FailNow()
+	}
+}
+
+func TestService_SetWith(t *testing.T) {
+	store := redisstore.NewMemoryStore(redisstore.DEFAULT, 0)
+	s := NewServiceWithStore(store, testPrefix)
+	if err := s.SetWith("plain", "hello", 1*time.Minute, WithInlineSerializer(serializer.String)); err != nil {
+		t.FailNow()
+	}
+	var raw []byte
+	if err := s.Get("plain", &raw); err != nil || string(raw) != "hello" {
+		t.FailNow()
+	}
+	var v string
+	if err := s.GetWith("plain", &v, WithInlineSerializer(serializer.String)); err != nil || v != "hello" {
+		t.FailNow()
+	}
+}
+
+func TestService_GetDel(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	if err := s.Set("otp", "123456", 1*time.Minute); err != nil {
+		t.FailNow()
+	}
+	var v string
+	if err := s.GetDel("otp", &v); err != nil || v != "123456" {
+		t.FailNow()
+	}
+	if err := s.GetDel("otp", &v); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+}
+
+func TestService_Copy(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.DeleteMulti("copy_src", "copy_dst")
+	if err := s.Set("copy_src", "v1", time.Minute); err != nil {
+		t.FailNow()
+	}
+	if ok, err := s.Copy("copy_src", "copy_dst", false); err != nil || !ok {
+		t.FailNow()
+	}
+	if ok, err := s.Copy("copy_src", "copy_dst", false); err != nil || ok {
+		t.FailNow()
+	}
+	if ok, err := s.Copy("copy_src", "copy_dst", true); err != nil || !ok {
+		t.FailNow()
+	}
+	if _, err := s.Copy("copy_missing", "copy_dst", true); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+}
+
+func TestService_GetTime(t *testing.T) {
+	s := NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), testPrefix)
+	now := time.Date(2020, 5, 1, 12, 30, 0, 500, time.FixedZone("UTC+8", 8*3600))
+	if err := s.Set("time", now, 1*time.Minute); err != nil {
+		t.FailNow()
+	}
+	var raw []byte
+	if err := s.Get("time", &raw); err != nil || string(raw) != "2020-05-01T12:30:00.0000005+08:00" {
+		t.FailNow()
+	}
+	var v time.Time
+	if err := s.Get("time", &v); err != nil || !v.Equal(now) {
+		t.FailNow()
+	}
+}
+
+func TestService_WithContext(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	ctx, cancel := context.WithCancel(context.Background())
+	cs := s.WithContext(ctx)
+	if err := cs.Set("ctx", "hello", 1*time.Minute); err != nil {
+		t.FailNow()
+	}
+	cancel()
+	var v string
+	if err := cs.Get("ctx", &v); err != context.Canceled {
+		t.FailNow()
+	}
+	if err := s.Delete("ctx"); err != nil {
+		t.FailNow()
+	}
+}
+
+func TestService_ExistsMulti(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.Delete("present")
+	if err := s.Set("present", 1, 1*time.Minute); err != nil {
+		t.FailNow()
+	}
+	found, err := s.ExistsMulti("present", "absent")
+	if err != nil || !found["present"] || found["absent"] {
+		t.FailNow()
+	}
+}
+
+func TestService_SetNil(t *testing.T) {
+	s := NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), testPrefix)
+	if err := s.Set("nil", nil, 1*time.Minute); err != redisstore.ErrNilValue {
+		t.FailNow()
+	}
+	s = NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), testPrefix, WithNilPolicy(NilDelete))
+	if err := s.Set("nil", "hello", 1*time.Minute); err != nil {
+		t.FailNow()
+	}
+	var p *string
+	if err := s.Set("nil", p, 1*time.Minute); err != nil {
+		t.FailNow()
+	}
+	var v string
+	if err := s.Get("nil", &v); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+}
+
+func TestService_BinaryKeys(t *testing.T) {
+	s := NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), testPrefix, WithKeyHasher(Base64KeyEncoder))
+	key := string([]byte{0xff, ':', 0x00})
+	if err := s.Set(key, "hello", 1*time.Minute); err != nil {
+		t.FailNow()
+	}
+	if s.Key(key) != testPrefix+":_zoA" || !s.Exists(key) {
+		t.FailNow()
+	}
+}
+
+func TestService_CompareAndSwap(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "aggregate"
+	defer s.Delete(key)
+	if ok, err := s.CompareAndSwap(key, 0, 1, time.Minute); err != nil || ok {
+		t.FailNow()
+	}
+	if ok, err := s.CompareAndSwap(key, 0, 1, time.Minute, WithCreateIfMissing()); err != nil || !ok {
+		t.FailNow()
+	}
+	if ok, err := s.CompareAndSwap(key, 0, 2, time.Minute); err != nil || ok {
+		t.FailNow()
+	}
+	if ok, err := s.CompareAndSwap(key, 1, 2, time.Minute); err != nil || !ok {
+		t.FailNow()
+	}
+}
+
+func TestService_SetIfChanged(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.Delete("config")
+	if changed, err := s.SetIfChanged("config", "v1", time.Minute); err != nil || !changed {
+		t.FailNow()
+	}
+	if changed, err := s.SetIfChanged("config", "v1", time.Minute); err != nil || changed {
+		t.FailNow()
+	}
+	if changed, err := s.SetIfChanged("config", "v2", time.Minute); err != nil || !changed {
+		t.FailNow()
+	}
+}
+
+func TestService_IncrementMulti(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.DeleteMulti("views", "clicks", "label")
+	if err := s.Set("views", 10, time.Minute); err != nil {
+		t.FailNow()
+	}
+	values, err := s.IncrementMulti(map[string]int64{"views": 5, "clicks": -2})
+	if err != nil || values["views"] != 15 || values["clicks"] != -2 {
+		t.FailNow()
+	}
+	if err := s.Set("label", "text", time.Minute); err != nil {
+		t.FailNow()
+	}
+	values, err = s.IncrementMulti(map[string]int64{"views": 1, "label": 1})
+	if err == nil || values["views"] != 16 || len(values) != 1 {
+		t.FailNow()
+	}
+}
+
+func TestService_IncrementMod(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.Delete("rotating_id")
+	if n, err := s.IncrementMod("rotating_id", 9998, 10000); err != nil || n != 9998 {
+		t.FailNow()
+	}
+	if n, err := s.IncrementMod("rotating_id", 1, 10000); err != nil || n != 9999 {
+		t.FailNow()
+	}
+	if n, err := s.IncrementMod("rotating_id", 3, 10000); err != nil || n != 2 {
+		t.FailNow()
+	}
+	if _, err := s.IncrementMod("rotating_id", 1, 0); err == nil {
+		t.FailNow()
+	}
+	if _, err := s.IncrementMod("rotating_id", 1, 1<<52+1); err == nil {
+		t.FailNow()
+	}
+	// wrapped values above 1e14 are written back in decimal, and deltas
+	// above MaxInt64 reduced to the modulus
+	const modulus = 1 << 52
+	if n, err := s.IncrementMod("rotating_id", modulus-3, modulus); err != nil || n != modulus-1 {
+		t.FailNow()
+	}
+	if n, err := s.IncrementMod("rotating_id", modulus-1, modulus); err != nil || n != modulus-2 {
+		t.FailNow()
+	}
+	if n, err := s.IncrementMod("rotating_id", 1, modulus); err != nil || n != modulus-1 {
+		t.FailNow()
+	}
+	if n, err := s.IncrementMod("rotating_id", math.MaxUint64, modulus); err != nil || n != modulus-2 {
+		t.FailNow()
+	}
+}
+
+func TestService_Heartbeat(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.Delete("worker:1")
+	if created, err := s.Heartbeat("worker:1", "alive", time.Second); err != nil || !created {
+		t.FailNow()
+	}
+	if created, err := s.Heartbeat("worker:1", "alive", time.Minute); err != nil || created {
+		t.FailNow()
+	}
+	if ttl, err := s.TTL("worker:1"); err != nil || ttl <= time.Second {
+		t.FailNow()
+	}
+}
+
+func TestService_ExpireAt(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix, WithServerClock())
+	defer s.Delete("expire_at")
+	now, err := s.ServerTime()
+	if err != nil || now.IsZero() {
+		t.FailNow()
+	}
+	if err := s.Set("expire_at", 1, redisstore.FOREVER); err != nil {
+		t.FailNow()
+	}
+	if ok, err := s.ExpireAt("expire_at", time.Now().Add(time.Minute)); err != nil || !ok {
+		t.FailNow()
+	}
+	if ttl, err := s.TTL("expire_at"); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.FailNow()
+	}
+}
+
+func TestService_SetForever(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewServiceWithStore(redisstore.NewRedisCacheWithPool(p, time.Minute), testPrefix)
+	key := "forever"
+	defer s.Delete(key)
+	if err := s.Set(key, 1, redisstore.FOREVER); err != nil {
+		t.FailNow()
+	}
+	if ttl, err := s.TTL(key); err != nil || ttl != redisstore.FOREVER {
+		t.FailNow()
+	}
+	if err := s.Set(key, 1, redisstore.DEFAULT); err != nil {
+		t.FailNow()
+	}
+	if ttl, err := s.TTL(key); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.FailNow()
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	calls := 0
+	err := RetryPolicy{Attempts: 3}.do(func() error {
+		calls++
+		return io.ErrUnexpectedEOF
+	})
+	if err != io.ErrUnexpectedEOF || calls != 3 {
+		t.FailNow()
+	}
+	calls = 0
+	err = RetryPolicy{Attempts: 3}.do(func() error {
+		calls++
+		return redisstore.ErrCacheMiss
+	})
+	if err != redisstore.ErrCacheMiss || calls != 1 {
+		t.FailNow()
+	}
+}
+
+func TestService_WithCompressor(t *testing.T) {
+	store := redisstore.NewMemoryStore(redisstore.DEFAULT, 0)
+	defer store.Close()
+	gz := NewServiceWithStore(store, testPrefix, WithCompressor(serializer.Gzip, 64))
+	large := strings.Repeat("compressible ", 100)
+	if err := gz.Set("large", large, time.Minute); err != nil {
+		t.FailNow()
+	}
+	if err := gz.Set("small", "tiny", time.Minute); err != nil {
+		t.FailNow()
+	}
+	var raw []byte
+	plain := NewServiceWithStore(store, testPrefix)
+	if err := plain.GetWith("large", &raw); err != nil || len(raw) >= len(large) {
+		t.FailNow()
+	}
+	zl := NewServiceWithStore(store, testPrefix, WithCompressor(serializer.Zlib, 64))
+	var v string
+	if err := zl.Get("large", &v); err != nil || v != large {
+		t.FailNow()
+	}
+	if err := zl.Get("small", &v); err != nil || v != "tiny" {
+		t.FailNow()
+	}
+}
+
+func TestService_WithCompressor_SnappyZstd(t *testing.T) {
+	// values decompress with the compressor that wrote them, whichever is configured
+	store := redisstore.NewMemoryStore(redisstore.DEFAULT, 0)
+	defer store.Close()
+	large := strings.Repeat("compressible ", 100)
+	if err := NewServiceWithStore(store, testPrefix, WithCompressor(serializer.Zstd, 64)).Set("large", large, time.Minute); err != nil {
+		t.FailNow()
+	}
+	var v string
+	if err := NewServiceWithStore(store, testPrefix, WithCompressor(serializer.Snappy, 64)).Get("large", &v); err != nil || v != large {
+		t.FailNow()
+	}
+}
+
+func TestService_WithSerializer_Tagged(t *testing.T) {
+	store := redisstore.NewMemoryStore(redisstore.DEFAULT, 0)
+	defer store.Close()
+	legacy := NewServiceWithStore(store, testPrefix)
+	type item struct{ Name string }
+	if err := legacy.Set("old", item{Name: "gob"}, time.Minute); err != nil {
+		t.FailNow()
+	}
+	migrating := NewServiceWithStore(store, testPrefix, WithSerializer(serializer.Tagged(serializer.FormatJSON)))
+	var v item
+	if err := migrating.Get("old", &v); err != nil || v.Name != "gob" {
+		t.FailNow()
+	}
+	if err := migrating.Set("new", item{Name: "json"}, time.Minute); err != nil {
+		t.FailNow()
+	}
+	var raw []byte
+	if err := legacy.GetWith("new", &raw); err != nil || string(raw) != "\x81{\"Name\":\"json\"}" {
+		t.FailNow()
+	}
+	gob := NewServiceWithStore(store, testPrefix, WithSerializer(serializer.Tagged(serializer.FormatGob)))
+	if err := gob.Get("new", &v); err != nil || v.Name != "json" {
+		t.FailNow()
+	}
+}
+
+func TestService_WithSerializer_Counters(t *testing.T) {
+	store := redisstore.NewMemoryStore(redisstore.DEFAULT, 0)
+	defer store.Close()
+	for _, ser := range []serializer.Serializer{
+		serializer.Tagged(serializer.FormatJSON),
+		serializer.JSON,
+		serializer.Compressed(serializer.Tagged(serializer.FormatGob), serializer.Gzip, 0),
+	} {
+		s := NewServiceWithStore(store, testPrefix, WithSerializer(ser))
+		if err := s.Set("counter", 5, time.Minute); err != nil {
+			t.FailNow()
+		}
+		if n, err := s.Increment("counter", 2); err != nil || n != 7 {
+			t.FailNow()
+		}
+		if n, err := s.Decrement("counter", 3); err != nil || n != 4 {
+			t.FailNow()
+		}
+		var v int
+		if err := s.Get("counter", &v); err != nil || v != 4 {
+			t.FailNow()
+		}
+	}
+}
+
+func TestService_WithSerializer_ValuePaths(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix, WithSerializer(serializer.Tagged(serializer.FormatJSON)))
+	type item struct{ Name string }
+	defer s.Delete("cas")
+	if swapped, err := s.CompareAndSwap("cas", item{}, item{Name: "a"}, time.Minute, WithCreateIfMissing()); err != nil || !swapped {
+		t.FailNow()
+	}
+	if swapped, err := s.CompareAndSwap("cas", item{Name: "a"}, item{Name: "b"}, time.Minute); err != nil || !swapped {
+		t.FailNow()
+	}
+	var v item
+	if err := s.Get("cas", &v); err != nil || v.Name != "b" {
+		t.FailNow()
+	}
+	defer s.Delete("multi")
+	if _, err := s.AddMulti(map[string]interface{}{"multi": item{Name: "m"}}, time.Minute); err != nil {
+		t.FailNow()
+	}
+	if err := s.Get("multi", &v); err != nil || v.Name != "m" {
+		t.FailNow()
+	}
+	defer s.Delete("list")
+	if _, err := s.LPush("list", item{Name: "l"}); err != nil {
+		t.FailNow()
+	}
+	if i, err := s.LPos("list", item{Name: "l"}); err != nil || i != 0 {
+		t.FailNow()
+	}
+	if err := s.RPop("list", &v); err != nil || v.Name != "l" {
+		t.FailNow()
+	}
+	defer s.Delete("hash")
+	if err := s.HSet("hash", "f", item{Name: "h"}); err != nil {
+		t.FailNow()
+	}
+	if err := s.HGet("hash", "f", &v); err != nil || v.Name != "h" {
+		t.FailNow()
+	}
+	defer s.Delete("zset")
+	if _, err := s.ZAdd("zset", 2, item{Name: "z"}); err != nil {
+		t.FailNow()
+	}
+	if score, err := s.ZScore("zset", item{Name: "z"}); err != nil || score != 2 {
+		t.FailNow()
+	}
+}
+
+func TestService_RequireExpiration(t *testing.T) {
+	s := NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), testPrefix, RequireExpiration())
+	if err := s.Set("leak", 1, redisstore.DEFAULT); err != ErrNoExpiration {
+		t.FailNow()
+	}
+	if err := s.Add("leak", 1, redisstore.DEFAULT); err != ErrNoExpiration {
+		t.FailNow()
+	}
+	if err := s.Set("bounded", 1, time.Minute); err != nil {
+		t.FailNow()
+	}
+	if err := s.Set("forever", 1, redisstore.FOREVER); err != nil {
+		t.FailNow()
+	}
+	withDefault := NewServiceWithStore(redisstore.NewMemoryStore(time.Minute, 0), testPrefix, RequireExpiration())
+	if err := withDefault.Set("default", 1, redisstore.DEFAULT); err != nil {
+		t.FailNow()
+	}
+}
+
+func TestService_MaxValueSize(t *testing.T) {
+	s := NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), testPrefix, MaxValueSize(16))
+	huge := strings.Repeat("x", 17)
+	if err := s.Set("huge", huge, time.Minute); err != ErrValueTooLarge {
+		t.FailNow()
+	}
+	if err := s.Add("huge", huge, time.Minute); err != ErrValueTooLarge {
+		t.FailNow()
+	}
+	if err := s.SetWith("huge", huge, time.Minute); err != ErrValueTooLarge {
+		t.FailNow()
+	}
+	if s.Exists("huge") {
+		t.FailNow()
+	}
+	if err := s.Set("small", "ok", time.Minute); err != nil {
+		t.FailNow()
+	}
+	var v string
+	if err := s.Get("small", &v); err != nil || v != "ok" {
+		t.FailNow()
+	}
+
+	compressed := NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), testPrefix,
+		WithCompressor(serializer.Gzip, 0), MaxValueSize(64))
+	if err := compressed.Set("huge", strings.Repeat("x", 1024), time.Minute); err != nil {
+		t.FailNow()
+	}
+}
+
+func TestService_DecodeError(t *testing.T) {
+	type profile struct {
+		Name string
+	}
+	s := NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), testPrefix)
+	if err := s.SetWith("profile", 42, time.Minute, WithInlineSerializer(serializer.JSON)); err != nil {
+		t.FailNow()
+	}
+	var p profile
+	err := s.Get("profile", &p)
+	var decodeErr *DecodeError
+	if !errors.Is(err, ErrTypeMismatch) || !errors.As(err, &decodeErr) || decodeErr.Type != "*goredis.profile" {
+		t.FailNow()
+	}
+
+	missing := NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), testPrefix, MissOnDecodeError())
+	if err := missing.SetWith("profile", 42, time.Minute, WithInlineSerializer(serializer.JSON)); err != nil {
+		t.FailNow()
+	}
+	if err := missing.Get("profile", &p); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+	err = missing.GetOrSetContext(context.Background(), "profile", &p, time.Minute,
+		func(ctx context.Context) (interface{}, error) {
+			return profile{Name: "gopher"}, nil
+		})
+	if err != nil || p.Name != "gopher" {
+		t.FailNow()
+	}
+	if err := missing.Get("profile", &p); err != nil || p.Name != "gopher" {
+		t.FailNow()
+	}
+}
+
+func TestService_AllowFlush(t *testing.T) {
+	s := NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), testPrefix)
+	if err := s.Set("kept", 1, time.Minute); err != nil {
+		t.FailNow()
+	}
+	if err := s.FlushAll(); err != redisstore.ErrNotSupport || !s.Exists("kept") {
+		t.FailNow()
+	}
+	allowed := NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), testPrefix, AllowFlush())
+	if err := allowed.Set("flushed", 1, time.Minute); err != nil {
+		t.FailNow()
+	}
+	if err := allowed.FlushAll(); err != nil || allowed.Exists("flushed") {
+		t.FailNow()
+	}
+}
+
+func TestService_WithCounters(t *testing.T) {
+	s := NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), testPrefix)
+	if _, err := s.Increment("hits", 1); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+	auto := NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), testPrefix,
+		WithCounters(CounterConfig{AutoCreate: true}))
+	if n, err := auto.Increment("hits", 2); err != nil || n != 2 {
+		t.FailNow()
+	}
+	if n, err := auto.Decrement("misses", 1); err != nil || n != 0 {
+		t.FailNow()
+	}
+}
+
+func TestService_WithCounters_MissContract(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix, WithCounters(CounterConfig{AutoCreate: false}))
+	defer s.DeleteMulti("strict_id", "strict_views", "strict_board")
+	if _, err := s.IncrementMod("strict_id", 1, 10); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+	if values, err := s.IncrementMulti(map[string]int64{"strict_views": 1}); err != nil || len(values) != 0 {
+		t.FailNow()
+	}
+	if _, err := s.ZIncrBy("strict_board", 1, "alice"); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+	if err := s.Set("strict_id", 9, time.Minute); err != nil {
+		t.FailNow()
+	}
+	if n, err := s.IncrementMod("strict_id", 1, 10); err != nil || n != 0 {
+		t.FailNow()
+	}
+}
+
+func TestService_SlidingExpiration(t *testing.T) {
+	s := NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), testPrefix,
+		WithExpiration(SlidingExpiration(time.Second)))
+	if err := s.Set("sliding", "a", 200*time.Millisecond); err != nil {
+		t.FailNow()
+	}
+	if err := s.Set("absolute", "a", 200*time.Millisecond); err != nil {
+		t.FailNow()
+	}
+	time.Sleep(150 * time.Millisecond)
+	var v string
+	if err := s.Get("sliding", &v); err != nil {
+		t.FailNow()
+	}
+	if err := s.GetWith("absolute", &v, WithExpirationPolicy(AbsoluteExpiration)); err != nil {
+		t.FailNow()
+	}
+	time.Sleep(150 * time.Millisecond)
+	if err := s.Get("sliding", &v); err != nil || v != "a" {
+		t.FailNow()
+	}
+	if err := s.Get("absolute", &v); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+}
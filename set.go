@@ -0,0 +1,74 @@
+package goredis
+
+// Set members are serialized like values, with the configured serializer.
+// Members returned by SMembers and the set algebra methods are in that stored
+// form: []byte and integer members read back verbatim under the default
+// serializer, other types need to be decoded.
+//
+// The multi-key operations below require all keys to hash to the same slot on
+// Redis Cluster, otherwise the server rejects them with a CROSSSLOT error.
+
+func (s *Service) SAdd(key string, members ...interface{}) (int, error) {
+	members, err := s.encodeAll(members)
+	if err != nil {
+		return 0, err
+	}
+	return s.store.SAdd(s.cacheKey(key), members...)
+}
+
+func (s *Service) SMembers(key string) ([]string, error) {
+	return s.store.SMembers(s.cacheKey(key))
+}
+
+// SScan calls fn for each member matching pattern, without loading the whole
+// set like SMembers would
+func (s *Service) SScan(key, pattern string, fn func(member string) error) error {
+	return s.store.SScan(s.cacheKey(key), pattern, fn)
+}
+
+// SPop removes a random member into ptrValue, redisstore.ErrCacheMiss when the set is empty
+func (s *Service) SPop(key string, ptrValue interface{}) error {
+	return s.decodeFrom(key, ptrValue, func(b *[]byte) error {
+		return s.store.SPop(s.cacheKey(key), b)
+	})
+}
+
+// SMove atomically moves member from src to dst, e.g. to claim a queued work
+// item into an in-progress set, and reports whether member was in src
+func (s *Service) SMove(src, dst string, member interface{}) (bool, error) {
+	member, err := s.encode(member)
+	if err != nil {
+		return false, err
+	}
+	return s.store.SMove(s.cacheKey(src), s.cacheKey(dst), member)
+}
+
+func (s *Service) SInter(keys ...string) ([]string, error) {
+	return s.store.SInter(s.cacheKeys(keys)...)
+}
+
+func (s *Service) SUnion(keys ...string) ([]string, error) {
+	return s.store.SUnion(s.cacheKeys(keys)...)
+}
+
+func (s *Service) SDiff(keys ...string) ([]string, error) {
+	return s.store.SDiff(s.cacheKeys(keys)...)
+}
+
+// SInterCard returns the size of the intersection of keys without transferring
+// its members, stopping at limit when positive (Redis 7+, emulated before)
+func (s *Service) SInterCard(keys []string, limit int) (int64, error) {
+	return s.store.SInterCard(s.cacheKeys(keys), limit)
+}
+
+func (s *Service) SInterStore(dst string, keys ...string) (int, error) {
+	return s.store.SInterStore(s.cacheKey(dst), s.cacheKeys(keys)...)
+}
+
+func (s *Service) SUnionStore(dst string, keys ...string) (int, error) {
+	return s.store.SUnionStore(s.cacheKey(dst), s.cacheKeys(keys)...)
+}
+
+func (s *Service) SDiffStore(dst string, keys ...string) (int, error) {
+	return s.store.SDiffStore(s.cacheKey(dst), s.cacheKeys(keys)...)
+}
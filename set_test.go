@@ -0,0 +1,91 @@
+package goredis
+
+import (
+	"testing"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+func TestService_SInter(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.DeleteMulti("segment_a", "segment_b", "segment_ab")
+	if _, err := s.SAdd("segment_a", 1, 2, 3); err != nil {
+		t.FailNow()
+	}
+	if _, err := s.SAdd("segment_b", 2, 3, 4); err != nil {
+		t.FailNow()
+	}
+	members, err := s.SInter("segment_a", "segment_b")
+	if err != nil || len(members) != 2 {
+		t.FailNow()
+	}
+	if n, err := s.SUnionStore("segment_ab", "segment_a", "segment_b"); err != nil || n != 4 {
+		t.FailNow()
+	}
+}
+
+func TestService_SInterCard(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.DeleteMulti("audience_a", "audience_b")
+	if _, err := s.SAdd("audience_a", 1, 2, 3, 4); err != nil {
+		t.FailNow()
+	}
+	if _, err := s.SAdd("audience_b", 2, 3, 4, 5); err != nil {
+		t.FailNow()
+	}
+	if n, err := s.SInterCard([]string{"audience_a", "audience_b"}, 0); err != nil || n != 3 {
+		t.FailNow()
+	}
+	if n, err := s.SInterCard([]string{"audience_a", "audience_b"}, 2); err != nil || n != 2 {
+		t.FailNow()
+	}
+}
+
+func TestService_SPop(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.DeleteMulti("jobs", "jobs_running")
+	if _, err := s.SAdd("jobs", "job1"); err != nil {
+		t.FailNow()
+	}
+	var job string
+	if err := s.SPop("jobs", &job); err != nil || job != "job1" {
+		t.FailNow()
+	}
+	if err := s.SPop("jobs", &job); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+	if _, err := s.SAdd("jobs", "job2"); err != nil {
+		t.FailNow()
+	}
+	if moved, err := s.SMove("jobs", "jobs_running", "job2"); err != nil || !moved {
+		t.FailNow()
+	}
+	if moved, err := s.SMove("jobs", "jobs_running", "job2"); err != nil || moved {
+		t.FailNow()
+	}
+}
+
+func TestService_SScan(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "ids"
+	defer s.Delete(key)
+	if _, err := s.SAdd(key, 1, 10, 11, 2); err != nil {
+		t.FailNow()
+	}
+	var members []string
+	err := s.SScan(key, "1*", func(member string) error {
+		members = append(members, member)
+		return nil
+	})
+	if err != nil || len(members) != 3 {
+		t.FailNow()
+	}
+}
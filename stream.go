@@ -0,0 +1,75 @@
+package goredis
+
+import (
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+	"github.com/owngoals/go-redis/serializer"
+)
+
+// StreamEntry is an entry read from a stream
+type StreamEntry struct {
+	ID     string
+	Fields map[string][]byte
+	codec  serializer.Serializer
+}
+
+// Field deserializes the value of field into ptrValue, redisstore.ErrCacheMiss
+// when the entry has no such field
+func (e StreamEntry) Field(field string, ptrValue interface{}) error {
+	b, ok := e.Fields[field]
+	if !ok {
+		return redisstore.ErrCacheMiss
+	}
+	return e.codec.Deserialize(b, ptrValue)
+}
+
+// XAdd appends an entry to the stream, serializing field values like values,
+// and returns its generated ID
+func (s *Service) XAdd(key string, fields map[string]interface{}) (string, error) {
+	encoded := make(map[string]interface{}, len(fields))
+	for field, value := range fields {
+		b, err := s.codec().Serialize(value)
+		if err != nil {
+			return "", err
+		}
+		encoded[field] = b
+	}
+	return s.store.XAdd(s.cacheKey(key), encoded)
+}
+
+// XRead returns up to count entries with an ID greater than lastID, "0" reads
+// the stream from the start. It does not block when there are none.
+func (s *Service) XRead(key, lastID string, count int) ([]StreamEntry, error) {
+	entries, err := s.store.XRead(s.cacheKey(key), lastID, count)
+	return s.streamEntries(entries), err
+}
+
+// XGroupCreate creates a consumer group, and the stream if needed, delivering
+// entries after startID, "$" for new entries only. An existing group is left
+// as is, so it can be called on every startup.
+func (s *Service) XGroupCreate(key, group, startID string) error {
+	return s.store.XGroupCreate(s.cacheKey(key), group, startID)
+}
+
+// XReadGroup reads up to count new entries for consumer, waiting up to block
+// when there are none, zero does not wait. Entries stay pending until XAck.
+func (s *Service) XReadGroup(key, group, consumer string, count int, block time.Duration) ([]StreamEntry, error) {
+	entries, err := s.store.XReadGroup(s.cacheKey(key), group, consumer, count, block)
+	return s.streamEntries(entries), err
+}
+
+func (s *Service) XAck(key, group string, ids ...string) (int, error) {
+	return s.store.XAck(s.cacheKey(key), group, ids...)
+}
+
+func (s *Service) streamEntries(entries []redisstore.StreamEntry) []StreamEntry {
+	if entries == nil {
+		return nil
+	}
+	out := make([]StreamEntry, len(entries))
+	for i, e := range entries {
+		out[i] = StreamEntry{ID: e.ID, Fields: e.Fields, codec: s.codec()}
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package goredis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestService_XRead(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "events"
+	defer s.Delete(key)
+	id, err := s.XAdd(key, map[string]interface{}{"type": "login", "user": 7})
+	if err != nil || id == "" {
+		t.FailNow()
+	}
+	entries, err := s.XRead(key, "0", 10)
+	if err != nil || len(entries) != 1 || entries[0].ID != id {
+		t.FailNow()
+	}
+	var typ string
+	var user int
+	if entries[0].Field("type", &typ) != nil || entries[0].Field("user", &user) != nil {
+		t.FailNow()
+	}
+	if typ != "login" || user != 7 {
+		t.FailNow()
+	}
+	if entries, err := s.XRead(key, id, 10); err != nil || len(entries) != 0 {
+		t.FailNow()
+	}
+}
+
+func TestService_XReadGroup(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "events_group"
+	defer s.Delete(key)
+	for i := 0; i < 2; i++ {
+		if err := s.XGroupCreate(key, "workers", "$"); err != nil {
+			t.FailNow()
+		}
+	}
+	id, err := s.XAdd(key, map[string]interface{}{"n": 1})
+	if err != nil {
+		t.FailNow()
+	}
+	entries, err := s.XReadGroup(key, "workers", "w1", 10, 0)
+	if err != nil || len(entries) != 1 || entries[0].ID != id {
+		t.FailNow()
+	}
+	if entries, err := s.XReadGroup(key, "workers", "w1", 10, 100*time.Millisecond); err != nil || len(entries) != 0 {
+		t.FailNow()
+	}
+	if n, err := s.XAck(key, "workers", id); err != nil || n != 1 {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,88 @@
+package goredis
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// reapBatch bounds the fields deleted by a single reaper script run
+const reapBatch = 100
+
+// TTLMap is a map of T stored in a Redis hash whose entries expire on their
+// own. Redis 7.4+ expires fields natively; on older servers expirations are
+// tracked in a sidecar sorted set at key + ":ttl": expired entries are hidden
+// from Get and must be deleted with Reap or RunReaper. On Redis Cluster, key
+// needs a hash tag, see WithHashTag, for the sidecar to share its slot.
+type TTLMap[T any] struct {
+	s   *Service
+	key string
+}
+
+// NewTTLMap returns the map stored in the hash at key
+func NewTTLMap[T any](s *Service, key string) *TTLMap[T] {
+	return &TTLMap[T]{s: s, key: key}
+}
+
+// Put sets field to value for ttl
+func (m *TTLMap[T]) Put(field string, value T, ttl time.Duration) error {
+	b, err := m.s.codec().Serialize(value)
+	if err != nil {
+		return err
+	}
+	_, err = m.s.store.HSetTTL(m.s.cacheKey(m.key), m.expirations(), field, b, ttl)
+	return err
+}
+
+// Get returns the value of field, ok is false when it is absent or expired
+func (m *TTLMap[T]) Get(field string) (value T, ok bool, err error) {
+	var b []byte
+	err = m.s.store.HGetTTL(m.s.cacheKey(m.key), m.expirations(), field, &b)
+	if err != nil {
+		return value, false, ignoreMiss(err)
+	}
+	err = m.s.decode(m.s.codec(), m.key, b, &value)
+	return value, err == nil, err
+}
+
+// Delete removes field and reports whether it was set
+func (m *TTLMap[T]) Delete(field string) (bool, error) {
+	return m.s.store.HDelTTL(m.s.cacheKey(m.key), m.expirations(), field)
+}
+
+// Reap deletes the entries tracked as expired by the sidecar and returns how
+// many it deleted. It is a no-op on servers expiring fields natively.
+func (m *TTLMap[T]) Reap() (int, error) {
+	total := 0
+	for {
+		n, err := m.s.store.ReapFields(m.s.cacheKey(m.key), m.expirations(), reapBatch)
+		total += n
+		if err != nil || n < reapBatch {
+			return total, err
+		}
+	}
+}
+
+// RunReaper calls Reap every interval until ctx is done, then returns the ctx
+// error, or until Reap fails, then returns its error. interval must be positive.
+func (m *TTLMap[T]) RunReaper(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("goredis: non-positive interval %v", interval)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := m.Reap(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (m *TTLMap[T]) expirations() string {
+	return m.s.cacheKey(m.key) + ":ttl"
+}
@@ -0,0 +1,52 @@
+package goredis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestTTLMap(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	defer s.DeleteMulti("sessions", "sessions:ttl")
+	sessions := NewTTLMap[string](s, "sessions")
+	if err := sessions.Put("alice", "token-a", time.Minute); err != nil {
+		t.FailNow()
+	}
+	if err := sessions.Put("bob", "token-b", 100*time.Millisecond); err != nil {
+		t.FailNow()
+	}
+	if v, ok, err := sessions.Get("alice"); err != nil || !ok || v != "token-a" {
+		t.FailNow()
+	}
+	time.Sleep(200 * time.Millisecond)
+	if _, ok, err := sessions.Get("bob"); err != nil || ok {
+		t.FailNow()
+	}
+	if _, err := sessions.Reap(); err != nil {
+		t.FailNow()
+	}
+	if deleted, err := sessions.Delete("alice"); err != nil || !deleted {
+		t.FailNow()
+	}
+}
+
+func TestTTLMap_RunReaper(t *testing.T) {
+	refused := errors.New("connection refused")
+	p := &redis.Pool{Dial: func() (redis.Conn, error) { return nil, refused }}
+	defer p.Close()
+	sessions := NewTTLMap[string](NewService(p, testPrefix), "sessions")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := sessions.RunReaper(ctx, 0); err == nil || err == context.DeadlineExceeded {
+		t.FailNow()
+	}
+	if err := sessions.RunReaper(ctx, 10*time.Millisecond); !errors.Is(err, refused) {
+		t.FailNow()
+	}
+}
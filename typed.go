@@ -0,0 +1,42 @@
+package goredis
+
+import (
+	"context"
+	"time"
+)
+
+// GetTyped is Get returning the value instead of filling an out-parameter
+func GetTyped[T any](s *Service, key string) (T, error) {
+	var value T
+	err := s.Get(key, &value)
+	return value, err
+}
+
+// SetTyped is Set constrained to values of type T, the counterpart of GetTyped
+func SetTyped[T any](s *Service, key string, value T, expires time.Duration) error {
+	return s.Set(key, value, expires)
+}
+
+// PublishTyped is Publish constrained to messages of type T, for SubscribeTyped
+// subscribers
+func PublishTyped[T any](s *Service, channel string, msg T) (int, error) {
+	return s.Publish(channel, msg)
+}
+
+// SubscribeTyped is Subscribe decoding every payload into a T with the configured
+// serializer before calling handler. Messages that fail to decode are skipped and
+// reported to the WithDecodeErrorHandler option, if any.
+func SubscribeTyped[T any](ctx context.Context, s *Service, channels []string, handler func(channel string, msg T),
+	opts ...CallOption) error {
+	o := s.callOptions(opts)
+	return s.Subscribe(ctx, channels, func(channel string, data []byte) {
+		var msg T
+		if err := o.serializer.Deserialize(data, &msg); err != nil {
+			if o.onDecodeError != nil {
+				o.onDecodeError(channel, err)
+			}
+			return
+		}
+		handler(channel, msg)
+	}, opts...)
+}
@@ -0,0 +1,26 @@
+package goredis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+func TestGetTyped(t *testing.T) {
+	s := NewServiceWithStore(redisstore.NewMemoryStore(redisstore.DEFAULT, 0), testPrefix)
+	type user struct {
+		Name string
+		Age  int
+	}
+	if err := SetTyped(s, "user", user{Name: "a", Age: 3}, time.Minute); err != nil {
+		t.FailNow()
+	}
+	u, err := GetTyped[user](s, "user")
+	if err != nil || u.Name != "a" || u.Age != 3 {
+		t.FailNow()
+	}
+	if _, err := GetTyped[user](s, "missing"); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,57 @@
+package goredis
+
+import (
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+// GetWithVersion is Get also returning the version the value was stamped with
+// by WithVersioning, 0 for unversioned values
+func (s *Service) GetWithVersion(key string, ptrValue interface{}) (int64, error) {
+	return s.getVersion(key, ptrValue, nil)
+}
+
+// VersionKey returns the Redis key of the counter WithVersioning takes the
+// versions of key from
+func (s *Service) VersionKey(key string) string {
+	return s.reservedKey("version", key)
+}
+
+// deleteVersions removes the WithVersioning counters of keys
+func (s *Service) deleteVersions(keys ...string) error {
+	if !s.versioned {
+		return nil
+	}
+	for _, key := range keys {
+		if err := s.store.DeleteIgnoreMissing(s.VersionKey(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write stores the encoded value of key, only if key is missing when onlyIf is
+// "NX" or present when it is "XX", stamping it with a version under WithVersioning
+func (s *Service) write(key string, value interface{}, expires time.Duration, onlyIf string) error {
+	cacheKey := s.cacheKey(key)
+	if s.versioned {
+		_, err := s.store.SetVersioned(cacheKey, s.VersionKey(key), value, expires, onlyIf)
+		return err
+	}
+	switch onlyIf {
+	case "NX":
+		return s.cache.Add(cacheKey, value, expires)
+	case "XX":
+		return s.cache.Replace(cacheKey, value, expires)
+	}
+	return s.cache.Set(cacheKey, value, expires)
+}
+
+// unversion strips the version WithVersioning stamps stored values with
+func (s *Service) unversion(b []byte) []byte {
+	if s.versioned {
+		_, b = redisstore.Unversion(b)
+	}
+	return b
+}
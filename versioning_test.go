@@ -0,0 +1,69 @@
+package goredis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+func TestService_WithVersioning(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix, WithVersioning())
+	key := "versioned"
+	defer s.Delete(key)
+	defer s.store.Delete(s.VersionKey(key))
+	if err := s.Set(key, "a", 0); err != nil {
+		t.FailNow()
+	}
+	var v string
+	first, err := s.GetWithVersion(key, &v)
+	if err != nil || v != "a" || first <= 0 {
+		t.FailNow()
+	}
+	if err := s.Set(key, "b", 0); err != nil {
+		t.FailNow()
+	}
+	second, err := s.GetWithVersion(key, &v)
+	if err != nil || v != "b" || second <= first {
+		t.FailNow()
+	}
+	if err := s.Get(key, &v); err != nil || v != "b" {
+		t.FailNow()
+	}
+	if err := s.Add(key, "c", 0); err == nil {
+		t.FailNow()
+	}
+	if version, err := s.GetWithVersion(key, &v); err != nil || version != second {
+		t.FailNow()
+	}
+	if changed, err := s.SetIfChanged(key, "b", 0); err != nil || changed {
+		t.FailNow()
+	}
+	if swapped, err := s.CompareAndSwap(key, "b", "c", 0); err != nil || !swapped {
+		t.FailNow()
+	}
+	if version, err := s.GetWithVersion(key, &v); err != nil || v != "c" || version <= second {
+		t.FailNow()
+	}
+	if values, err := s.GetMulti([]string{key}); err != nil || string(values[key]) != "c" {
+		t.FailNow()
+	}
+	if err := s.Set(key, "d", 300*time.Millisecond); err != nil {
+		t.FailNow()
+	}
+	time.Sleep(400 * time.Millisecond)
+	if err := s.Get(key, &v); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+	if s.store.Exists(s.VersionKey(key)) {
+		t.FailNow()
+	}
+	if err := s.Set(key, "e", 0); err != nil {
+		t.FailNow()
+	}
+	if err := s.Delete(key); err != nil || s.store.Exists(s.VersionKey(key)) {
+		t.FailNow()
+	}
+}
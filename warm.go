@@ -0,0 +1,65 @@
+package goredis
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// warmLockTTL bounds how long an instance may hold the warming lock of a
+	// key, so a crashed instance cannot block the others for longer
+	warmLockTTL = 30 * time.Second
+	// warmPollInterval is how often instances that lost the lock check whether
+	// the value has been warmed
+	warmPollInterval = 50 * time.Millisecond
+)
+
+// WarmOnce stores the result of loader under key unless it already exists,
+// coordinating through a short-lived SET NX lock so that when many instances
+// warm the same key only one of them calls loader. The others wait until the
+// value appears, or ctx is done, and take over if the lock is released without
+// it. The lock lives in the reserved namespace, holds a random token and is
+// only released by its holder, never once it expired and was taken by another
+// instance.
+func (s *Service) WarmOnce(ctx context.Context, key string, loader func(ctx context.Context) (interface{}, error),
+	expires time.Duration) error {
+	store := s.store.WithContext(ctx)
+	cacheKey := s.cacheKey(key)
+	lockKey := s.reservedKey("warming", key)
+	for {
+		found, err := store.ExistsMulti(cacheKey, lockKey)
+		if err != nil {
+			return err
+		}
+		if found[0] {
+			return nil
+		}
+		if found[1] {
+			timer := time.NewTimer(warmPollInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+			continue
+		}
+		token, err := randomToken()
+		if err != nil {
+			return err
+		}
+		locked, err := store.Lock(lockKey, token, warmLockTTL)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			continue
+		}
+		defer s.store.DeleteIfEqual(lockKey, token)
+		value, err := loader(ctx)
+		if err != nil {
+			return err
+		}
+		return s.WithContext(ctx).Set(key, value, expires)
+	}
+}
@@ -0,0 +1,79 @@
+package goredis
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestService_WarmOnce(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "warm"
+	defer s.Delete(key)
+	var calls int32
+	loader := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(100 * time.Millisecond)
+		return "warmed", nil
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- s.WarmOnce(context.Background(), key, loader, time.Minute)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.FailNow()
+		}
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.FailNow()
+	}
+	var v string
+	if err := s.Get(key, &v); err != nil || v != "warmed" {
+		t.FailNow()
+	}
+}
+
+func TestService_WarmOnce_ForeignLock(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	lockKey := s.reservedKey("warming", "warm_taken")
+	defer s.Delete("warm_taken")
+	defer s.store.Delete(lockKey)
+	err := s.WarmOnce(context.Background(), "warm_taken", func(ctx context.Context) (interface{}, error) {
+		// the lock expired and another instance took it while loading
+		return "warmed", s.store.Set(lockKey, "other", time.Minute)
+	}, time.Minute)
+	if err != nil {
+		t.FailNow()
+	}
+	var v string
+	if err := s.store.Get(lockKey, &v); err != nil || v != "other" {
+		t.FailNow()
+	}
+
+	// waiters give up once their context is done
+	if err := s.Delete("warm_taken"); err != nil {
+		t.FailNow()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = s.WarmOnce(ctx, "warm_taken", func(ctx context.Context) (interface{}, error) {
+		return "warmed", nil
+	}, time.Minute)
+	if err != context.DeadlineExceeded {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,82 @@
+package goredis
+
+import (
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+// WriteThrough is a secondary store mirroring the writes of a Service, e.g. a
+// database or a backup Redis. It receives the keys without the prefix and the
+// values before serialization.
+type WriteThrough interface {
+	Set(key string, value interface{}, expires time.Duration) error
+	Delete(key string) error
+}
+
+// WriteThroughPolicy decides what a failure of the secondary store does to the write
+type WriteThroughPolicy int
+
+const (
+	// WriteThroughBestEffort ignores failures of the secondary store
+	WriteThroughBestEffort WriteThroughPolicy = iota
+	// WriteThroughStrict returns failures of the secondary store from the write.
+	// Redis has already been written by then, the error only reports that the
+	// copy is missing.
+	WriteThroughStrict
+)
+
+type writeThrough struct {
+	store  WriteThrough
+	policy WriteThroughPolicy
+}
+
+// WithWriteThrough mirrors Set, SetWith, Add, Replace and the Delete variants to
+// w once they succeeded on Redis. Deletes are mirrored for missing keys too.
+func WithWriteThrough(w WriteThrough, policy WriteThroughPolicy) Option {
+	return func(s *Service) {
+		s.writeThrough = &writeThrough{store: w, policy: policy}
+	}
+}
+
+// mirrorSet copies a write that returned err to the WithEncodings copies and
+// the write-through store
+func (s *Service) mirrorSet(err error, key string, value interface{}, expires time.Duration) error {
+	if err == nil && len(s.encodings) > 0 {
+		err = s.setEncodings(key, value, expires)
+	}
+	if err != nil || s.writeThrough == nil {
+		return err
+	}
+	return s.writeThrough.result(s.writeThrough.store.Set(key, value, expires))
+}
+
+// mirrorDelete copies a delete that returned err to the WithEncodings copies,
+// the WithVersioning counters and the write-through store
+func (s *Service) mirrorDelete(err error, keys ...string) error {
+	if err == nil || err == redisstore.ErrCacheMiss {
+		if derr := s.deleteEncodings(keys...); derr != nil {
+			return derr
+		}
+		if derr := s.deleteVersions(keys...); derr != nil {
+			return derr
+		}
+	}
+	if (err != nil && err != redisstore.ErrCacheMiss) || s.writeThrough == nil {
+		return err
+	}
+	for _, key := range keys {
+		if werr := s.writeThrough.result(s.writeThrough.store.Delete(key)); werr != nil {
+			return werr
+		}
+	}
+	return err
+}
+
+// result applies the policy to an error of the write-through store
+func (w *writeThrough) result(err error) error {
+	if w.policy == WriteThroughStrict {
+		return err
+	}
+	return nil
+}
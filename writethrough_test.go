@@ -0,0 +1,54 @@
+package goredis
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+// mapWriteThrough records mirrored writes, failing them when err is set
+type mapWriteThrough struct {
+	values map[string]interface{}
+	err    error
+}
+
+func (m *mapWriteThrough) Set(key string, value interface{}, expires time.Duration) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.values[key] = value
+	return nil
+}
+
+func (m *mapWriteThrough) Delete(key string) error {
+	if m.err != nil {
+		return m.err
+	}
+	delete(m.values, key)
+	return nil
+}
+
+func TestService_WithWriteThrough(t *testing.T) {
+	backup := &mapWriteThrough{values: map[string]interface{}{}}
+	store := redisstore.NewMemoryStore(redisstore.DEFAULT, 0)
+	s := NewServiceWithStore(store, testPrefix, WithWriteThrough(backup, WriteThroughBestEffort))
+	if err := s.Set("mirrored", "v", time.Minute); err != nil || backup.values["mirrored"] != "v" {
+		t.FailNow()
+	}
+	if err := s.Delete("mirrored"); err != nil {
+		t.FailNow()
+	}
+	if _, ok := backup.values["mirrored"]; ok {
+		t.FailNow()
+	}
+	backup.err = errors.New("backup down")
+	if err := s.Set("mirrored", "v", time.Minute); err != nil {
+		t.FailNow()
+	}
+	strict := NewServiceWithStore(store, testPrefix, WithWriteThrough(backup, WriteThroughStrict))
+	if err := strict.Set("mirrored", "v", time.Minute); err != backup.err {
+		t.FailNow()
+	}
+}
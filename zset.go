@@ -0,0 +1,38 @@
+package goredis
+
+import "github.com/owngoals/go-redis/redisstore"
+
+// ZAdd sets the score of member, serialized like set members, and returns how
+// many members were added. Flags restrict the update, e.g. redisstore.ZAddGT
+// with redisstore.ZAddCH only raises scores and counts the changed members.
+func (s *Service) ZAdd(key string, score float64, member interface{}, flags ...redisstore.ZAddFlag) (int, error) {
+	member, err := s.encode(member)
+	if err != nil {
+		return 0, err
+	}
+	return s.store.ZAdd(s.cacheKey(key), score, member, flags...)
+}
+
+// ZIncrBy adds increment to the score of member and returns the new score. A
+// missing member is created at 0 by default, see WithCounters.
+func (s *Service) ZIncrBy(key string, increment float64, member interface{}) (float64, error) {
+	member, err := s.encode(member)
+	if err != nil {
+		return 0, err
+	}
+	return s.store.ZIncrBy(s.cacheKey(key), increment, member, s.autoCreate(true))
+}
+
+// ZScore returns the score of member, redisstore.ErrCacheMiss when it is not in the set
+func (s *Service) ZScore(key string, member interface{}) (float64, error) {
+	member, err := s.encode(member)
+	if err != nil {
+		return 0, err
+	}
+	return s.store.ZScore(s.cacheKey(key), member)
+}
+
+// ZScan calls fn for each member matching pattern with its score
+func (s *Service) ZScan(key, pattern string, fn func(member string, score float64) error) error {
+	return s.store.ZScan(s.cacheKey(key), pattern, fn)
+}
@@ -0,0 +1,51 @@
+package goredis
+
+import (
+	"testing"
+
+	"github.com/owngoals/go-redis/redisstore"
+)
+
+func TestService_ZAdd(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "leaderboard"
+	defer s.Delete(key)
+	if n, err := s.ZAdd(key, 10, "alice"); err != nil || n != 1 {
+		t.FailNow()
+	}
+	if n, err := s.ZAdd(key, 5, "alice", redisstore.ZAddGT, redisstore.ZAddCH); err != nil || n != 0 {
+		t.FailNow()
+	}
+	if n, err := s.ZAdd(key, 20, "alice", redisstore.ZAddGT, redisstore.ZAddCH); err != nil || n != 1 {
+		t.FailNow()
+	}
+	if score, err := s.ZIncrBy(key, 2.5, "alice"); err != nil || score != 22.5 {
+		t.FailNow()
+	}
+	if _, err := s.ZScore(key, "bob"); err != redisstore.ErrCacheMiss {
+		t.FailNow()
+	}
+}
+
+func TestService_ZScan(t *testing.T) {
+	p := CreatePool(testHost, testPort, testDb, testPassword)
+	defer p.Close()
+	s := NewService(p, testPrefix)
+	key := "scores"
+	defer s.Delete(key)
+	for member, score := range map[int]float64{1: 1.5, 2: 2.5} {
+		if _, err := s.ZAdd(key, score, member); err != nil {
+			t.FailNow()
+		}
+	}
+	total := 0.0
+	err := s.ZScan(key, "", func(member string, score float64) error {
+		total += score
+		return nil
+	})
+	if err != nil || total != 4 {
+		t.FailNow()
+	}
+}